@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/borderlesshq/restgen/gen"
+)
+
+// runCheck regenerates everything in memory and compares it with what is on
+// disk. It returns stale=true if any generated file is missing or differs.
+func runCheck(args []string) (stale bool, err error) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := gen.Generate(context.Background(), gen.Options{
+		Config: cfg,
+		DryRun: true,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var staleFiles []string
+	missing := make(map[string]bool)
+
+	for _, f := range res.Files {
+		existing, err := os.ReadFile(f.Path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return false, fmt.Errorf("reading %s: %w", f.Path, err)
+			}
+			missing[f.Path] = true
+			continue
+		}
+
+		// Create-only files belong to the user once they exist
+		if f.CreateOnly {
+			continue
+		}
+
+		if !bytes.Equal(existing, f.Content) {
+			staleFiles = append(staleFiles, f.Path)
+		}
+	}
+
+	if len(staleFiles) > 0 {
+		fmt.Println("Stale generated files:")
+		for _, path := range staleFiles {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Println("Missing generated files:")
+		for _, f := range res.Files {
+			if !missing[f.Path] {
+				continue
+			}
+			if f.Source != "" {
+				fmt.Printf("  %s (from %s)\n", f.Path, f.Source)
+			} else {
+				fmt.Printf("  %s\n", f.Path)
+			}
+		}
+	}
+
+	if len(staleFiles) > 0 || len(missing) > 0 {
+		fmt.Println("\nRun 'restgen generate' to update generated files.")
+		return true, nil
+	}
+
+	fmt.Println("Generated files are up to date.")
+	return false, nil
+}
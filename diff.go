@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/diff"
+	"github.com/borderlesshq/restgen/internal/ir"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// runDiff compares two versions of the schemas and reports breaking and
+// non-breaking changes. It returns breaking=true if any change is breaking.
+func runDiff(args []string) (breaking bool, err error) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	against := fs.String("against", "", "git ref to compare the configured schemas against")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return false, fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return false, err
+	}
+
+	var old, new map[string]ir.Schema
+	switch {
+	case *against != "" && fs.NArg() == 0:
+		fsys, err := gitFS(*against)
+		if err != nil {
+			return false, err
+		}
+		if old, err = loadSchemas(fsys, cfg, nil, ""); err != nil {
+			return false, fmt.Errorf("%s: %w", *against, err)
+		}
+		if new, err = loadSchemas(gen.OSFS{}, cfg, nil, ""); err != nil {
+			return false, err
+		}
+	case *against == "" && fs.NArg() == 2:
+		if old, err = loadDir(cfg, fs.Arg(0)); err != nil {
+			return false, err
+		}
+		if new, err = loadDir(cfg, fs.Arg(1)); err != nil {
+			return false, err
+		}
+	default:
+		return false, errors.New("usage: restgen diff [-c config.yaml] [-format text|json] (old/ new/ | -against git-ref)")
+	}
+
+	changes := diff.Compare(old, new)
+
+	if *format == "json" {
+		if changes == nil {
+			changes = []diff.Change{}
+		}
+		data, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("encoding changes: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(diff.Text(changes))
+	}
+
+	return diff.HasBreaking(changes), nil
+}
+
+// loadSchemas builds the IR of every target and returns its schemas keyed
+// by file, relative to root when it is set.
+func loadSchemas(fsys gen.FS, cfg *gen.Config, files []string, root string) (map[string]ir.Schema, error) {
+	targets, err := gen.Targets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make(map[string]ir.Schema)
+	for _, t := range targets {
+		targetFiles := files
+		if targetFiles == nil {
+			if targetFiles, err = gen.SchemaFiles(fsys, t); err != nil {
+				return nil, err
+			}
+		}
+		doc, err := gen.BuildIR(fsys, t, targetFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range doc.Schemas {
+			key := s.File
+			if root != "" {
+				if key, err = relTo(root, s.File); err != nil {
+					return nil, err
+				}
+			}
+			if _, seen := schemas[key]; !seen {
+				schemas[key] = s
+			}
+		}
+
+		// Explicit files are the same for every target
+		if files != nil {
+			break
+		}
+	}
+	return schemas, nil
+}
+
+// loadDir loads every SDL file under dir, keyed by its path within dir.
+func loadDir(cfg *gen.Config, dir string) (map[string]ir.Schema, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	files, err := findSchemas(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no schema files in %s", dir)
+	}
+	return loadSchemas(gen.OSFS{}, cfg, files, dir)
+}
+
+// findSchemas returns every SDL file under dir.
+func findSchemas(dir string) ([]string, error) {
+	exts := strings.ReplaceAll(strings.Join(schema.Extensions, ","), ".", "")
+	return gen.OSFS{}.Glob(filepath.Join(dir, "**", "*.{"+exts+"}"))
+}
+
+func relTo(root, file string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// gitFS returns the schema files under the working directory as they are
+// at ref, in memory.
+func gitFS(ref string) (*gen.MemFS, error) {
+	out, err := git("ls-tree", "-r", "--name-only", ref, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if name == "" || schema.BaseName(name) == filepath.Base(name) {
+			continue // not SDL
+		}
+		content, err := git("show", ref+":./"+name)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return gen.NewMemFS(files), nil
+}
+
+func git(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -95,6 +95,7 @@ func (h *ContactsHandler) CreateContact(w http.ResponseWriter, r *http.Request)
 		Message: "CreateContact not implemented",
 	})
 }
+
 func (h *ContactsHandler) PatchContacts(w http.ResponseWriter, r *http.Request) {
 	var payload []models.CreateContactInput
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -109,6 +110,7 @@ func (h *ContactsHandler) PatchContacts(w http.ResponseWriter, r *http.Request)
 		Message: "PatchContacts not implemented",
 	})
 }
+
 func (h *ContactsHandler) GetContact(w http.ResponseWriter, r *http.Request) {
 	// Path parameters:
 	// id := chi.URLParam(r, "id")
@@ -118,6 +120,7 @@ func (h *ContactsHandler) GetContact(w http.ResponseWriter, r *http.Request) {
 		Message: "GetContact not implemented",
 	})
 }
+
 func (h *ContactsHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
 	// Path parameters:
 	// id := chi.URLParam(r, "id")
@@ -134,6 +137,7 @@ func (h *ContactsHandler) UpdateContact(w http.ResponseWriter, r *http.Request)
 		Message: "UpdateContact not implemented",
 	})
 }
+
 func (h *ContactsHandler) DeleteContact(w http.ResponseWriter, r *http.Request) {
 	// Path parameters:
 	// id := chi.URLParam(r, "id")
@@ -143,6 +147,7 @@ func (h *ContactsHandler) DeleteContact(w http.ResponseWriter, r *http.Request)
 		Message: "DeleteContact not implemented",
 	})
 }
+
 func (h *ContactsHandler) ListContacts(w http.ResponseWriter, r *http.Request) {
 	// Query parameters:
 	var filter models.ContactFilter
@@ -160,6 +165,7 @@ func (h *ContactsHandler) ListContacts(w http.ResponseWriter, r *http.Request) {
 		Message: "ListContacts not implemented",
 	})
 }
+
 func (h *ContactsHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	// Path parameters:
 	// iso2 := chi.URLParam(r, "iso2")
@@ -177,6 +183,7 @@ func (h *ContactsHandler) UpdateLocation(w http.ResponseWriter, r *http.Request)
 		Message: "UpdateLocation not implemented",
 	})
 }
+
 func (h *ContactsHandler) SearchLocations(w http.ResponseWriter, r *http.Request) {
 	// Query parameters:
 	var query models.LocationQuery
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/borderlesshq/restgen/gen"
+)
+
+// runExplain prints how the generated handler for one call binds its
+// arguments, what it responds with, and the stub restgen emits for it.
+func runExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	targetName := fs.String("target", "", "target whose config applies (required when the config has several)")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: restgen explain [-c config.yaml] [-target name] [-format text|json] file.sdl call")
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return err
+	}
+	target, err := selectTarget(cfg, *targetName)
+	if err != nil {
+		return err
+	}
+
+	ex, err := gen.Explain(gen.OSFS{}, target, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(ex, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding explanation: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("%s: %s %s\n", ex.Call, ex.Method, ex.FullPath)
+	fmt.Printf("Handler: %s\n", ex.Handler)
+	fmt.Printf("Response: shared.ApiResponse[%s]\n", ex.Response)
+
+	fmt.Println()
+	if len(ex.Args) == 0 {
+		fmt.Println("No arguments.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ARG\tSOURCE\tSDL TYPE\tGO TYPE\tDECODER")
+		for _, a := range ex.Args {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Name, a.Source, a.Type, a.GoType, a.Decoder)
+		}
+		w.Flush()
+
+		fmt.Println()
+		for _, a := range ex.Args {
+			fmt.Printf("%s is read from the %s: %s\n", a.Name, a.Source, a.Reason)
+		}
+	}
+
+	if ex.Stub != "" {
+		fmt.Println()
+		fmt.Println("Stub:")
+		fmt.Println()
+		fmt.Print(ex.Stub)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/sdlfmt"
+)
+
+// runFmt formats SDL files. With no arguments it formats every configured
+// schema; "-" reads stdin and writes stdout. It returns unformatted=true
+// when -l listed files that need formatting.
+func runFmt(args []string) (unformatted bool, err error) {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	write := fs.Bool("w", false, "write the result to the file instead of stdout")
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	fs.Parse(args)
+
+	if fs.NArg() == 1 && fs.Arg(0) == "-" {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return false, err
+		}
+		out, err := sdlfmt.Source(src)
+		if err != nil {
+			return false, fmt.Errorf("<stdin>:%w", err)
+		}
+		_, err = os.Stdout.Write(out)
+		return false, err
+	}
+
+	files, err := fmtFiles(fs, *configPath)
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		out, err := sdlfmt.Source(src)
+		if err != nil {
+			return false, fmt.Errorf("%s:%w", path, err)
+		}
+		changed := !bytes.Equal(src, out)
+
+		if *list && changed {
+			fmt.Println(path)
+			unformatted = true
+		}
+		if *write {
+			if changed {
+				if err := os.WriteFile(path, out, 0644); err != nil {
+					return false, err
+				}
+			}
+		} else if !*list {
+			os.Stdout.Write(out)
+		}
+	}
+
+	// -l -w is "fix and report", which isn't a failure
+	return unformatted && !*write, nil
+}
+
+// fmtFiles returns the SDL files named on the command line, with
+// directories expanded, or every configured schema if none are.
+func fmtFiles(fs *flag.FlagSet, configPath string) ([]string, error) {
+	if fs.NArg() == 0 {
+		cfg, err := loadConfig(fs, configPath)
+		if err != nil {
+			return nil, err
+		}
+		return gen.SchemaFiles(gen.OSFS{}, cfg)
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := findSchemas(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
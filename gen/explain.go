@@ -0,0 +1,92 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/emitter"
+	"github.com/borderlesshq/restgen/internal/routes"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// CallBinding describes how the generated handler for a call reads its
+// arguments and what it responds with.
+type CallBinding = emitter.CallBinding
+
+// ArgBinding is how one argument of a call reaches its handler.
+type ArgBinding = emitter.ArgBinding
+
+// Explanation is everything restgen decides about a single call.
+type Explanation struct {
+	CallBinding
+	Schema   string `json:"schema"`
+	FullPath string `json:"fullPath"` // @base + call path
+	Stub     string `json:"stub"`     // the handler stub as generated, "" if a custom template doesn't emit one
+}
+
+// Explain describes how the handler for call in schemaFile binds its
+// arguments, and returns the stub Generate would emit for it. cfg must be
+// a single target; pass one of Targets(cfg).
+func Explain(fsys FS, cfg *Config, schemaFile, call string) (*Explanation, error) {
+	if len(cfg.Targets) > 0 {
+		return nil, errors.New("config has targets; explain a call of a single target")
+	}
+
+	g, err := newGenerator(cfg, fsys)
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.parseSchema(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	if s.Library {
+		return nil, fmt.Errorf("%s is a @library schema and generates no handlers", schemaFile)
+	}
+
+	binding, err := g.routesEmitter.Binding(&s, call)
+	if err != nil {
+		var names []string
+		for _, c := range s.Calls {
+			names = append(names, c.Name)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: %w; it has no calls", schemaFile, err)
+		}
+		return nil, fmt.Errorf("%s: %w; it has %s", schemaFile, err, strings.Join(names, ", "))
+	}
+
+	content, err := g.routesEmitter.Emit(&s)
+	if err != nil {
+		return nil, fmt.Errorf("emitting routes for %s: %w", schemaFile, err)
+	}
+	routesFile := filepath.Join(cfg.Output, schema.BaseName(schemaFile)+"_routes.go")
+	formatted, err := formatSource(routesFile, []byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("routes emitter produced invalid Go for %s: %w", schemaFile, err)
+	}
+
+	return &Explanation{
+		CallBinding: *binding,
+		Schema:      schemaFile,
+		FullPath:    routes.Join(s.Base, binding.Path),
+		Stub:        handlerStub(string(formatted), binding.Handler),
+	}, nil
+}
+
+// handlerStub extracts the method handler ("ContactsHandler.GetContact")
+// from generated routes.
+func handlerStub(src, handler string) string {
+	recv, method, _ := strings.Cut(handler, ".")
+	start := strings.Index(src, "func (h *"+recv+") "+method+"(")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(src[start:], "\n}\n")
+	if end < 0 {
+		return ""
+	}
+	return src[start : start+end+3]
+}
@@ -0,0 +1,29 @@
+package gen
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/tools/imports"
+)
+
+// formatSource formats generated Go source and fixes its imports in memory,
+// the same way goimports would if the file already lived at path.
+func formatSource(path string, src []byte) ([]byte, error) {
+	// imports resolves sibling packages relative to the file's location
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := imports.Process(abs, src, &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", path, err)
+	}
+
+	return formatted, nil
+}
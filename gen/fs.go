@@ -0,0 +1,159 @@
+package gen
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/borderlesshq/restgen/internal/writer"
+)
+
+// FS is where restgen reads SDL files and existing generated files from,
+// and where it writes generated output.
+//
+// Paths are slash- or OS-separated file paths as they appear in the config
+// (e.g., "routes/contacts_routes.go"); the parser may also ask for absolute
+// paths when resolving @include. Missing files must be reported with an
+// error that satisfies errors.Is(err, fs.ErrNotExist).
+type FS interface {
+	// ReadFile returns the content of the named file.
+	ReadFile(name string) ([]byte, error)
+
+	// Glob returns the names of all files matching pattern, using the
+	// syntax of filepath.Match extended with ** for any number of
+	// directories (see github.com/bmatcuk/doublestar).
+	Glob(pattern string) ([]string, error)
+
+	// WriteFiles writes every file or, on failure, none of them.
+	WriteFiles(files []File) error
+}
+
+// OSFS is the local filesystem. Writes are transactional: every file is
+// staged next to its destination and renamed into place, and the tree is
+// restored if anything fails.
+type OSFS struct{}
+
+// ReadFile implements FS.
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// Glob implements FS.
+func (OSFS) Glob(pattern string) ([]string, error) {
+	return doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+}
+
+// WriteFiles implements FS.
+func (OSFS) WriteFiles(files []File) error {
+	txn := writer.New()
+	for _, f := range files {
+		txn.Add(f.Path, f.Content)
+	}
+	return txn.Commit()
+}
+
+// MemFS is an in-memory FS, useful for tests and dry runs. Names are
+// normalized to absolute paths, so "schemas/a.sdl" and "./schemas/a.sdl"
+// refer to the same file. The zero value is not usable; use NewMemFS.
+type MemFS struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemFS creates an in-memory FS holding files (name -> content).
+func NewMemFS(files map[string][]byte) *MemFS {
+	m := &MemFS{files: make(map[string][]byte)}
+	for name, content := range files {
+		m.files[memKey(name)] = content
+	}
+	return m
+}
+
+// ReadFile implements FS.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	content, ok := m.files[memKey(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return content, nil
+}
+
+// Glob implements FS. Matches are returned relative to the working
+// directory when pattern is relative.
+func (m *MemFS) Glob(pattern string) ([]string, error) {
+	if !doublestar.ValidatePathPattern(pattern) {
+		return nil, doublestar.ErrBadPattern
+	}
+
+	absPattern := memKey(pattern)
+	cwd, _ := os.Getwd()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []string
+	for name := range m.files {
+		if ok, _ := doublestar.PathMatch(absPattern, name); !ok {
+			continue
+		}
+		if !filepath.IsAbs(pattern) && cwd != "" {
+			if rel, err := filepath.Rel(cwd, name); err == nil {
+				matches = append(matches, rel)
+				continue
+			}
+		}
+		matches = append(matches, name)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// WriteFiles implements FS.
+func (m *MemFS) WriteFiles(files []File) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, f := range files {
+		m.files[memKey(f.Path)] = f.Content
+	}
+	return nil
+}
+
+// Files returns a copy of every file in the FS, keyed by absolute path.
+func (m *MemFS) Files() map[string][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	files := make(map[string][]byte, len(m.files))
+	for name, content := range m.files {
+		files[name] = content
+	}
+	return files
+}
+
+func memKey(name string) string {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return filepath.Clean(name)
+	}
+	return abs
+}
+
+// exists reports whether name exists in fsys.
+func exists(fsys FS, name string) (bool, error) {
+	_, err := fsys.ReadFile(name)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
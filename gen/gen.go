@@ -0,0 +1,476 @@
+// Package gen is the programmatic API for restgen. The restgen CLI is a thin
+// wrapper around it, so build tools, tests and //go:generate drivers can run
+// exactly the same pipeline:
+//
+//	cfg, err := gen.Load("restgen.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	res, err := gen.Generate(ctx, gen.Options{Config: cfg})
+//
+// Reading SDL and writing output go through FS, so generation can run
+// entirely in memory with MemFS.
+package gen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/emitter"
+	internalparser "github.com/borderlesshq/restgen/internal/parser"
+	"github.com/borderlesshq/restgen/internal/schema"
+	"github.com/borderlesshq/restgen/internal/verify"
+)
+
+// Config is the restgen.yaml configuration.
+type Config = config.Config
+
+// Schema is the parsed representation of a single SDL file.
+type Schema = schema.Schema
+
+// TypeError is a type-checking error in generated code, mapped back to
+// the SDL call or type that produced it.
+type TypeError = verify.Error
+
+// File is a single generated output.
+type File struct {
+	Path    string // output path (e.g., "routes/contacts_routes.go")
+	Content []byte // final formatted content
+	Source  string // SDL file that produced it; empty for dependencies.go and plugin output
+	Emitter string // emitter that produced it: "routes", "types", "dependencies" or "plugin:<name>"
+	Target  string // name of the target that produced it; empty without targets
+
+	// Schema is the parsed SDL that produced this file, used to map
+	// diagnostics in the generated code back to calls and types.
+	Schema *Schema
+
+	// CreateOnly files are written only if they don't exist yet.
+	CreateOnly bool
+
+	// Merge metadata for routes files
+	PreservedMethods []string
+	RemovedMethods   []string
+}
+
+// Options configures a Generate run.
+type Options struct {
+	// Config is required.
+	Config *Config
+
+	// FS is where SDL is read from and output written to.
+	// Defaults to the local filesystem.
+	FS FS
+
+	// SchemaFiles restricts generation to these SDL files.
+	// Defaults to every file matched by Config.Schemas. With targets, each
+	// target only generates the files that it matches.
+	SchemaFiles []string
+
+	// Workers is the number of schemas processed in parallel.
+	// Zero means one per CPU.
+	Workers int
+
+	// Verify type-checks the generated code with go/types before writing
+	// and refuses to write if it doesn't compile. The output must live
+	// inside a Go module on disk.
+	Verify bool
+
+	// DryRun generates everything in memory but writes nothing.
+	DryRun bool
+}
+
+// Result is the output of a Generate run.
+type Result struct {
+	// Files is everything generated, in schema order.
+	Files []File
+
+	// Written is the subset of Files that was written. Create-only files
+	// that already exist are skipped; nothing is written on a dry run.
+	Written []File
+
+	// Includes maps each schema file to the SDL files it transitively
+	// includes (absolute paths).
+	Includes map[string][]string
+}
+
+// VerifyError is returned by Generate when Options.Verify is set and the
+// generated code does not type-check.
+type VerifyError struct {
+	Errors []TypeError
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("generated code does not type-check (%d errors)", len(e.Errors))
+}
+
+// Load reads configuration from a YAML file. Unknown keys are errors
+// (with line numbers), and ${VAR} or ${VAR:-default} is replaced with the
+// environment variable VAR.
+func Load(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// LoadOrDefault is Load, but returns the default configuration if path
+// doesn't exist.
+func LoadOrDefault(path string) (*Config, error) {
+	return config.LoadOrDefault(path)
+}
+
+// ConfigSchema returns the JSON Schema for restgen.yaml.
+func ConfigSchema() []byte {
+	return config.JSONSchema
+}
+
+// Parse parses SDL files from the local filesystem.
+func Parse(files ...string) ([]*Schema, error) {
+	return ParseFS(OSFS{}, files...)
+}
+
+// ParseFS parses SDL files from fsys, resolving @include relative to each
+// file.
+func ParseFS(fsys FS, files ...string) ([]*Schema, error) {
+	p := internalparser.NewWithReader(fsys.ReadFile)
+
+	var schemas []*Schema
+	for _, f := range files {
+		s, err := p.ParseFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", f, err)
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+// Targets resolves cfg into one config per target, inheriting unset
+// settings from the top level. A config without targets is its own single
+// target.
+func Targets(cfg *Config) ([]*Config, error) {
+	return cfg.Resolve()
+}
+
+// DependenciesFile is the path of the create-only dependencies.go that
+// Generate writes into the output directory of cfg, a single target.
+func DependenciesFile(cfg *Config) string {
+	return filepath.Join(cfg.Output, "dependencies.go")
+}
+
+// SchemaFiles expands cfg.Schemas into the list of SDL files in fsys. With
+// targets, it returns every file matched by any target, without duplicates.
+func SchemaFiles(fsys FS, cfg *Config) ([]string, error) {
+	if len(cfg.Targets) > 0 {
+		targets, err := Targets(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var schemaFiles []string
+		seen := make(map[string]bool)
+		for _, t := range targets {
+			files, err := SchemaFiles(fsys, t)
+			if err != nil {
+				return nil, fmt.Errorf("target %s: %w", t.Name, err)
+			}
+			for _, f := range files {
+				if !seen[filepath.Clean(f)] {
+					seen[filepath.Clean(f)] = true
+					schemaFiles = append(schemaFiles, f)
+				}
+			}
+		}
+		return schemaFiles, nil
+	}
+
+	var schemaFiles []string
+	seen := make(map[string]bool)
+	for _, pattern := range cfg.Schemas {
+		matches, err := fsys.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("glob pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			// Overlapping patterns (e.g. *.sdl and **/*.sdl) match twice
+			if seen[filepath.Clean(match)] {
+				continue
+			}
+			seen[filepath.Clean(match)] = true
+
+			excluded, err := isExcluded(match, cfg.Exclude)
+			if err != nil {
+				return nil, err
+			}
+			if !excluded {
+				schemaFiles = append(schemaFiles, match)
+			}
+		}
+	}
+
+	if len(schemaFiles) == 0 {
+		return nil, fmt.Errorf("no schema files found matching patterns: %v", cfg.Schemas)
+	}
+
+	return schemaFiles, nil
+}
+
+// Generate runs the full pipeline: parse, emit, merge with existing
+// routes, format, validate and (unless DryRun) write. Writing is
+// all-or-nothing; if anything fails, nothing is written.
+func Generate(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Config == nil {
+		return nil, errors.New("gen: Options.Config is required")
+	}
+
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = OSFS{}
+	}
+
+	targets, err := Targets(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Includes: make(map[string][]string)}
+	for _, t := range targets {
+		schemaFiles, err := targetSchemaFiles(fsys, t, opts.SchemaFiles, len(targets) > 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(schemaFiles) == 0 {
+			continue
+		}
+
+		g, err := newGenerator(t, fsys)
+		if err != nil {
+			return nil, err
+		}
+		g.workers = opts.Workers
+
+		tres, err := g.generate(ctx, schemaFiles)
+		if err != nil {
+			if t.Name != "" {
+				return nil, fmt.Errorf("target %s: %w", t.Name, err)
+			}
+			return nil, err
+		}
+
+		for _, f := range tres.Files {
+			f.Target = t.Name
+			res.Files = append(res.Files, f)
+		}
+		for schemaFile, includes := range tres.Includes {
+			res.Includes[schemaFile] = includes
+		}
+	}
+
+	res.Files, err = checkConflicts(res.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	var toWrite []File
+	for _, f := range res.Files {
+		if f.CreateOnly {
+			// Generate dependencies.go once (if it doesn't exist)
+			ok, err := exists(fsys, f.Path)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				continue
+			}
+		}
+		toWrite = append(toWrite, f)
+	}
+
+	if err := validate(toWrite); err != nil {
+		return nil, err
+	}
+
+	if opts.Verify {
+		var files []verify.File
+		for _, f := range toWrite {
+			files = append(files, verify.File{Path: f.Path, Content: f.Content, Source: f.Source, Schema: f.Schema})
+		}
+		typeErrs, err := verify.Verify(files)
+		if err != nil {
+			return nil, fmt.Errorf("verifying generated code: %w", err)
+		}
+		bindingErrs, err := verify.CheckBindings(bindings(res.Files))
+		if err != nil {
+			return nil, fmt.Errorf("verifying @goModel bindings: %w", err)
+		}
+		typeErrs = append(typeErrs, bindingErrs...)
+		if len(typeErrs) > 0 {
+			return nil, &VerifyError{Errors: typeErrs}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := fsys.WriteFiles(toWrite); err != nil {
+		return nil, err
+	}
+	res.Written = toWrite
+
+	return res, nil
+}
+
+// isExcluded reports whether path matches any of the exclude patterns.
+func isExcluded(path string, exclude []string) (bool, error) {
+	for _, pattern := range exclude {
+		ok, err := doublestar.PathMatch(filepath.Clean(pattern), filepath.Clean(path))
+		if err != nil {
+			return false, fmt.Errorf("exclude pattern %s: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// targetSchemaFiles returns the schema files to generate for target t.
+// Without targets, an explicit list is used as-is; with targets, it is
+// narrowed to the files t matches.
+func targetSchemaFiles(fsys FS, t *Config, only []string, multi bool) ([]string, error) {
+	if len(only) > 0 && !multi {
+		return only, nil
+	}
+
+	matched, err := SchemaFiles(fsys, t)
+	if err != nil {
+		if t.Name != "" {
+			return nil, fmt.Errorf("target %s: %w", t.Name, err)
+		}
+		return nil, err
+	}
+	if len(only) == 0 {
+		return matched, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, f := range only {
+		wanted[filepath.Clean(f)] = true
+	}
+
+	var schemaFiles []string
+	for _, f := range matched {
+		if wanted[filepath.Clean(f)] {
+			schemaFiles = append(schemaFiles, f)
+		}
+	}
+	return schemaFiles, nil
+}
+
+// checkConflicts fails if two outputs would be written to the same path,
+// e.g. two targets sharing an output directory and a schema file name.
+// Targets sharing an output directory share its dependencies.go, so
+// duplicate create-only files are dropped instead.
+func checkConflicts(files []File) ([]File, error) {
+	owners := make(map[string]File)
+	var out []File
+	for _, f := range files {
+		key := filepath.Clean(f.Path)
+		prev, ok := owners[key]
+		if !ok {
+			owners[key] = f
+			out = append(out, f)
+			continue
+		}
+		if f.CreateOnly && prev.CreateOnly {
+			continue
+		}
+		return nil, fmt.Errorf("%s is generated twice: by %s and by %s", f.Path, describe(prev), describe(f))
+	}
+	return out, nil
+}
+
+// describe names what produced f, for error messages.
+func describe(f File) string {
+	what := f.Emitter
+	if f.Source != "" {
+		what += " for " + f.Source
+	}
+	if f.Target != "" {
+		what += " in target " + f.Target
+	}
+	return what
+}
+
+// validate parses every .go file to make sure it is valid Go.
+func validate(files []File) error {
+	fset := token.NewFileSet()
+	for _, f := range files {
+		if !strings.HasSuffix(f.Path, ".go") {
+			continue
+		}
+		if _, err := parser.ParseFile(fset, f.Path, f.Content, parser.AllErrors); err != nil {
+			return fmt.Errorf("generated %s is not valid Go: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// BuiltinTemplates returns the source of the built-in templates, keyed by
+// the file name that overrides them in Config.Templates ("routes.tmpl",
+// "types.tmpl" and "dependencies.tmpl").
+func BuiltinTemplates() map[string]string {
+	return emitter.BuiltinTemplates()
+}
+
+// bindings collects the @goModel bindings declared by the schemas behind
+// files, once per schema.
+func bindings(files []File) []verify.Binding {
+	var out []verify.Binding
+	seen := make(map[*Schema]bool)
+	for _, f := range files {
+		if f.Schema == nil || seen[f.Schema] {
+			continue
+		}
+		seen[f.Schema] = true
+
+		add := func(kind, name, goModel string, pos schema.Pos, fields []schema.Field) {
+			mapping := config.ScalarFromString(goModel)
+			if mapping.Import == "" {
+				return
+			}
+			_, typeName, ok := strings.Cut(mapping.Type, ".")
+			if !ok {
+				typeName = mapping.Type
+			}
+			out = append(out, verify.Binding{
+				Source:     f.Source,
+				Node:       kind + " " + name,
+				Pos:        pos,
+				ImportPath: mapping.Import,
+				TypeName:   typeName,
+				Fields:     fields,
+			})
+		}
+		for _, t := range f.Schema.Types {
+			if t.GoModel != "" {
+				add("type", t.Name, t.GoModel, t.Pos, t.Fields)
+			}
+		}
+		for _, t := range f.Schema.Inputs {
+			if t.GoModel != "" {
+				add("input", t.Name, t.GoModel, t.Pos, t.Fields)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,333 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/emitter"
+	"github.com/borderlesshq/restgen/internal/gomod"
+	"github.com/borderlesshq/restgen/internal/merger"
+	"github.com/borderlesshq/restgen/internal/parser"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// generator runs the parse → emit → merge → format pipeline in memory.
+// Nothing is written; Generate decides what to do with the result.
+type generator struct {
+	// workers is the number of schemas processed in parallel.
+	// Zero means one per CPU.
+	workers int
+
+	cfg           *config.Config
+	fsys          FS
+	modules       *gomod.Resolver // nil outside a module
+	parser        *parser.Parser
+	routesEmitter *emitter.RoutesEmitter
+	typesEmitter  *emitter.TypesEmitter
+	depsEmitter   *emitter.DependenciesEmitter
+	merger        *merger.Merger
+}
+
+// newGenerator creates a generator for the given config, reading SDL,
+// template overrides and existing routes from fsys.
+func newGenerator(cfg *config.Config, fsys FS) (*generator, error) {
+	g := &generator{
+		cfg:           cfg,
+		fsys:          fsys,
+		parser:        parser.NewWithReader(fsys.ReadFile),
+		routesEmitter: emitter.NewRoutesEmitter(cfg),
+		typesEmitter:  emitter.NewTypesEmitter(cfg),
+		depsEmitter:   emitter.NewDependenciesEmitter(cfg.Package),
+		merger:        merger.New(),
+	}
+
+	modules, err := gomod.Find(".", fsys.ReadFile)
+	if err != nil {
+		return nil, fmt.Errorf("finding go.mod: %w", err)
+	}
+	g.modules = modules
+
+	if cfg.Templates != "" {
+		tmpls, err := emitter.LoadTemplates(cfg.Templates, fsys.ReadFile)
+		if err != nil {
+			return nil, err
+		}
+		g.routesEmitter.SetTemplate(tmpls.Routes)
+		g.typesEmitter.SetTemplate(tmpls.Types)
+		g.depsEmitter.SetTemplate(tmpls.Dependencies)
+	}
+
+	return g, nil
+}
+
+// generate produces the outputs for the given schema files.
+// Routes are merged against whatever currently exists in the FS.
+//
+// Schemas are processed in parallel, but the result lists files in the
+// same order as schemaFiles, followed by anything configured plugins
+// produced. Generation stops at the first error or when
+// ctx is cancelled.
+func (g *generator) generate(ctx context.Context, schemaFiles []string) (*Result, error) {
+	res := &Result{Includes: make(map[string][]string)}
+
+	// dependencies.go is generated once and never overwritten
+	depsFile := DependenciesFile(g.cfg)
+	depsContent, err := g.depsEmitter.Emit()
+	if err != nil {
+		return nil, fmt.Errorf("emitting dependencies: %w", err)
+	}
+	deps := File{
+		Path:       depsFile,
+		Content:    []byte(depsContent),
+		Emitter:    "dependencies",
+		CreateOnly: true,
+	}
+	if err := deps.format(); err != nil {
+		return nil, err
+	}
+	res.Files = append(res.Files, deps)
+
+	outputs := make([]schemaResult, len(schemaFiles))
+
+	workers := g.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(schemaFiles) {
+		workers = len(schemaFiles)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+		jobs   = make(chan int)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				outputs[i] = g.generateSchema(schemaFiles[i])
+				if outputs[i].err != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	// Stop handing out work as soon as anything fails
+	for i := range schemaFiles {
+		if failed.Load() || ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Report the first error in schema order so failures are deterministic
+	for _, out := range outputs {
+		if out.err != nil {
+			return nil, out.err
+		}
+	}
+
+	for _, out := range outputs {
+		res.Files = append(res.Files, out.files...)
+	}
+
+	pluginFiles, err := g.runPlugins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Files = append(res.Files, pluginFiles...)
+
+	// Already parsed, so this only walks the parser's cache
+	for _, schemaFile := range schemaFiles {
+		includes, err := g.parser.IncludeClosure(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		res.Includes[schemaFile] = includes
+	}
+
+	return res, nil
+}
+
+// schemaResult is what a single worker produced for one schema.
+type schemaResult struct {
+	files []File
+	err   error
+}
+
+// generateSchema parses, emits, merges and formats a single schema.
+func (g *generator) generateSchema(schemaFile string) schemaResult {
+	files, err := g.emitSchema(schemaFile)
+	if err != nil {
+		return schemaResult{err: err}
+	}
+
+	for i := range files {
+		if err := files[i].format(); err != nil {
+			return schemaResult{err: err}
+		}
+	}
+
+	return schemaResult{files: files}
+}
+
+// format formats the file in place. Generated code that doesn't format is
+// a bug in the emitter (or template) that produced it, so say which one.
+func (f *File) format() error {
+	formatted, err := formatSource(f.Path, f.Content)
+	if err != nil {
+		if f.Source != "" {
+			return fmt.Errorf("%s emitter produced invalid Go for %s: %w", f.Emitter, f.Source, err)
+		}
+		return fmt.Errorf("%s emitter produced invalid Go: %w", f.Emitter, err)
+	}
+	f.Content = formatted
+	return nil
+}
+
+func (g *generator) emitSchema(schemaFile string) ([]File, error) {
+	s, err := g.parseSchema(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+
+	// Derive handler name for this schema
+	baseName := schema.BaseName(schemaFile)
+
+	// Library schemas are include-only: they produce models but no routes
+	if s.Library && len(s.Calls) > 0 {
+		return nil, fmt.Errorf("%s: @library schemas can't define calls", schemaFile)
+	}
+
+	// Only generate routes if there are Calls defined
+	if len(s.Calls) > 0 {
+		routesContent, err := g.routesEmitter.Emit(&s)
+		if err != nil {
+			return nil, fmt.Errorf("emitting routes for %s: %w", schemaFile, err)
+		}
+
+		routesFile := filepath.Join(g.cfg.Output, baseName+"_routes.go")
+
+		// Merge with existing if present. A first write goes through the
+		// merger too, so regenerating it gives the same file.
+		existing, err := g.fsys.ReadFile(routesFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", routesFile, err)
+		}
+		merged, err := g.merger.MergeContent(routesContent, string(existing))
+		if err != nil {
+			return nil, fmt.Errorf("merging %s: %w", routesFile, err)
+		}
+
+		files = append(files, File{
+			Path:             routesFile,
+			Content:          []byte(merged.Content),
+			Source:           schemaFile,
+			Emitter:          "routes",
+			Schema:           &s,
+			PreservedMethods: merged.PreservedMethods,
+			RemovedMethods:   merged.RemovedMethods,
+		})
+	}
+
+	// Generate types if models path specified (from SDL or config default)
+	if s.Models != "" {
+		// Only generate types if there are types, inputs, or enums defined
+		// that aren't bound to existing Go types
+		if s.HasModels() {
+			typesContent, err := g.typesEmitter.Emit(&s)
+			if err != nil {
+				return nil, fmt.Errorf("emitting types for %s: %w", schemaFile, err)
+			}
+
+			modelsDir, err := g.modelsDir(s.Models)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", schemaFile, err)
+			}
+			typesFile := filepath.Join(modelsDir, baseName+"_types.go")
+
+			files = append(files, File{
+				Path:    typesFile,
+				Content: []byte(typesContent),
+				Source:  schemaFile,
+				Emitter: "types",
+				Schema:  &s,
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// parseSchema parses schemaFile and applies the config's models package to
+// it and the files it includes (see Config.ModelsFor).
+func (g *generator) parseSchema(schemaFile string) (schema.Schema, error) {
+	parsed, err := g.parser.ParseFile(schemaFile)
+	if err != nil {
+		return schema.Schema{}, fmt.Errorf("parsing %s: %w", schemaFile, err)
+	}
+
+	// The parser shares schemas between everything that includes them,
+	// so work on a copy before applying config defaults.
+	s := *parsed
+
+	// Use default models package from config if not specified in SDL, or
+	// the target's if it overrides @models. Included files get the same.
+	s.Models = g.cfg.ModelsFor(s.Models)
+	s.Includes = append([]schema.Include(nil), s.Includes...)
+	for i := range s.Includes {
+		s.Includes[i].Models = g.cfg.ModelsFor(s.Includes[i].Models)
+	}
+	return s, nil
+}
+
+// modelsDir returns the directory types for the models package pkg are
+// written to, relative to the working directory. It comes from the config
+// for the default package, from go.mod (or go.work) otherwise, and falls
+// back to the last element of the import path outside a module
+// (e.g., github.com/borderlesshq/api/models -> models/).
+func (g *generator) modelsDir(pkg string) (string, error) {
+	if g.cfg.Models.Dir != "" && pkg == g.cfg.Models.Package {
+		return g.cfg.Models.Dir, nil
+	}
+
+	if g.modules == nil {
+		parts := strings.Split(pkg, "/")
+		return parts[len(parts)-1], nil
+	}
+
+	dir, err := g.modules.Dir(pkg)
+	if err != nil {
+		return "", fmt.Errorf("models %w", err)
+	}
+
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(cwd, dir)
+	if err != nil {
+		return dir, nil
+	}
+	return rel, nil
+}
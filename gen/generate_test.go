@@ -0,0 +1,295 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+func TestGenerateConcurrently(t *testing.T) {
+	// Independent schemas, each with a call and a type
+	independent := func(n int) map[string]string {
+		files := make(map[string]string)
+		for i := 0; i < n; i++ {
+			files[fmt.Sprintf("schemas/s%d.sdl", i)] = fmt.Sprintf(`@base("/s%d")
+
+type Calls {
+    getThing(id: ID!): Thing @get("/{id}")
+}
+
+type Thing {
+    id: ID!
+}
+`, i)
+		}
+		return files
+	}
+
+	withCycle := independent(3)
+	withCycle["schemas/a.sdl"] = "@base(\"/a\")\n@include(\"b.sdl\")\n\ntype A {\n    id: ID!\n}\n"
+	withCycle["schemas/b.sdl"] = "@base(\"/b\")\n@include(\"a.sdl\")\n\ntype B {\n    id: ID!\n}\n"
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		workers int
+		wantErr string
+	}{
+		{name: "one worker", files: independent(4), workers: 1},
+		{name: "more schemas than workers", files: independent(5), workers: 2},
+		{name: "more workers than schemas", files: independent(2), workers: 8},
+		{name: "include cycle", files: withCycle, workers: 4, wantErr: "include cycle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []string
+			for run := 0; run < 3; run++ {
+				files := make(map[string][]byte)
+				for name, content := range tt.files {
+					files[name] = []byte(content)
+				}
+				cfg := config.DefaultConfig()
+				cfg.Schemas = []string{"schemas/*.sdl"}
+
+				type result struct {
+					res *Result
+					err error
+				}
+				done := make(chan result, 1)
+				go func() {
+					res, err := Generate(context.Background(), Options{
+						Config:  cfg,
+						FS:      NewMemFS(files),
+						Workers: tt.workers,
+						DryRun:  true,
+					})
+					done <- result{res, err}
+				}()
+
+				var r result
+				select {
+				case r = <-done:
+				case <-time.After(30 * time.Second):
+					t.Fatalf("run %d: Generate deadlocked", run)
+				}
+
+				if tt.wantErr != "" {
+					if r.err == nil || !strings.Contains(r.err.Error(), tt.wantErr) {
+						t.Fatalf("run %d: Generate error = %v, want %q", run, r.err, tt.wantErr)
+					}
+					continue
+				}
+				if r.err != nil {
+					t.Fatalf("run %d: Generate: %v", run, r.err)
+				}
+
+				// Output order doesn't depend on which worker finished first
+				var paths []string
+				for _, f := range r.res.Files {
+					paths = append(paths, f.Path)
+				}
+				if want == nil {
+					want = paths
+				} else if strings.Join(paths, " ") != strings.Join(want, " ") {
+					t.Fatalf("run %d: files = %v, want %v", run, paths, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateThenCheck(t *testing.T) {
+	schemas := map[string]string{
+		"schemas/contacts.sdl": `@base("/contacts")
+
+type Calls {
+    getContact(id: ID!): Contact @get("/{id}")
+    listContacts: [Contact!]! @get("/")
+    createContact(input: Contact!): Contact @post("/")
+}
+
+type Contact {
+    id: ID!
+}
+`,
+		"schemas/admin/users.sdl": `@base("/users")
+
+type Calls {
+    getUser(id: ID!): String @get("/{id}")
+    deleteUser(id: ID!): Boolean @delete("/{id}")
+}
+`,
+	}
+
+	targets := func(router string) []config.Target {
+		return []config.Target{
+			{Name: "public", Schemas: []string{"schemas/*.sdl"}, Output: "./public"},
+			{Name: "admin", Schemas: []string{"schemas/admin/*.sdl"}, Output: "./admin", Router: router},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		router  string
+		targets []config.Target
+	}{
+		{name: "chi", router: config.RouterChi},
+		{name: "stdlib", router: config.RouterStdlib},
+		{name: "chi targets", router: config.RouterChi, targets: targets(config.RouterChi)},
+		{name: "stdlib targets", router: config.RouterStdlib, targets: targets(config.RouterStdlib)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			for name, content := range schemas {
+				files[name] = []byte(content)
+			}
+			fsys := NewMemFS(files)
+
+			cfg := config.DefaultConfig()
+			cfg.Schemas = []string{"schemas/**/*.sdl"}
+			cfg.Router = tt.router
+			cfg.Targets = tt.targets
+
+			if _, err := Generate(context.Background(), Options{Config: cfg, FS: fsys}); err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+
+			// What restgen check does: regenerate in memory and compare
+			res, err := Generate(context.Background(), Options{Config: cfg, FS: fsys, DryRun: true})
+			if err != nil {
+				t.Fatalf("check: %v", err)
+			}
+			for _, f := range res.Files {
+				existing, err := fsys.ReadFile(f.Path)
+				if err != nil {
+					t.Errorf("%s: %v", f.Path, err)
+					continue
+				}
+				if !f.CreateOnly && string(existing) != string(f.Content) {
+					t.Errorf("%s is stale after generate:\n%s", f.Path, existing)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateTargetModels(t *testing.T) {
+	files := map[string][]byte{
+		"schemas/contacts.sdl": []byte(`@base("/contacts")
+@models("example.com/app/types")
+@include("geo.sdl")
+
+type Calls {
+    getContact(id: ID!): Contact @get("/{id}")
+}
+
+type Contact {
+    id: ID!
+    location: Location
+}
+`),
+		"schemas/geo.sdl": []byte(`@library
+@models("example.com/app/types")
+
+type Location {
+    lat: Float!
+}
+`),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Targets = []config.Target{
+		{
+			Name: "public", Schemas: []string{"schemas/*.sdl"}, Output: "./public",
+			Models: config.ModelsConfig{Package: "example.com/app/public/models", Dir: "public/models"},
+		},
+		{
+			Name: "admin", Schemas: []string{"schemas/*.sdl"}, Output: "./admin",
+			Models: config.ModelsConfig{Package: "example.com/app/admin/models", Dir: "admin/models"},
+		},
+	}
+
+	res, err := Generate(context.Background(), Options{Config: cfg, FS: NewMemFS(files), DryRun: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, f := range res.Files {
+		got[filepath.ToSlash(f.Path)] = string(f.Content)
+	}
+	for _, target := range []string{"public", "admin"} {
+		types, ok := got[target+"/models/contacts_types.go"]
+		if !ok {
+			t.Fatalf("no %s/models/contacts_types.go in %v", target, slices.Collect(maps.Keys(got)))
+		}
+		// The included library is in the same package, so unqualified
+		if !strings.Contains(types, "Location *Location") {
+			t.Errorf("%s types don't use the local Location:\n%s", target, types)
+		}
+		if _, ok := got[target+"/models/geo_types.go"]; !ok {
+			t.Errorf("no %s/models/geo_types.go", target)
+		}
+		routes := got[target+"/contacts_routes.go"]
+		if want := `"example.com/app/` + target + `/models"`; !strings.Contains(routes, want) {
+			t.Errorf("%s routes don't import %s:\n%s", target, want, routes)
+		}
+	}
+}
+
+func TestGenerateBoundToOwnModels(t *testing.T) {
+	files := map[string][]byte{
+		"schemas/orders.sdl": []byte(`@base("/orders")
+@models("example.com/app/types")
+
+type Calls {
+    getOrder(id: ID!): Order @get("/{id}")
+    getTotal(id: ID!): Money @get("/{id}/total")
+}
+
+type Money @goModel("example.com/app/types.Money")
+
+type Order {
+    id: ID!
+    total: Money!
+}
+`),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Schemas = []string{"schemas/*.sdl"}
+	res, err := Generate(context.Background(), Options{Config: cfg, FS: NewMemFS(files), DryRun: true})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, f := range res.Files {
+		got[filepath.ToSlash(f.Path)] = string(f.Content)
+	}
+
+	// The types file is in package types, so Money is local
+	types := got["types/orders_types.go"]
+	if strings.Contains(types, `"example.com/app/types"`) || !strings.Contains(types, "Total Money") {
+		t.Errorf("types file imports its own package or qualifies Money:\n%s", types)
+	}
+
+	// The routes file reaches Money through its single models import
+	routes := got["routes/orders_routes.go"]
+	if n := strings.Count(routes, `"example.com/app/types"`); n != 1 {
+		t.Errorf("routes file imports example.com/app/types %d times, want once:\n%s", n, routes)
+	}
+	if !strings.Contains(routes, "shared.ApiResponse[*models.Money]") {
+		t.Errorf("routes file doesn't use models.Money:\n%s", routes)
+	}
+}
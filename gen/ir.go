@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/ir"
+	internalparser "github.com/borderlesshq/restgen/internal/parser"
+	"github.com/borderlesshq/restgen/internal/plugin"
+)
+
+// IR is the versioned, JSON-serializable intermediate representation of a
+// set of schemas: every call, type, input, enum, directive and resolved
+// include, with source positions. It is what plugins receive.
+type IR = ir.Document
+
+// IRVersion is the version of the IR format produced by BuildIR.
+const IRVersion = ir.Version
+
+// BuildIR parses schemaFiles from fsys, following includes, and returns
+// the IR. If schemaFiles is empty, every file matched by cfg.Schemas is
+// used. A config with targets has one IR per target; pass one of
+// Targets(cfg).
+func BuildIR(fsys FS, cfg *Config, schemaFiles []string) (*IR, error) {
+	if len(cfg.Targets) > 0 {
+		return nil, errors.New("config has targets; build the IR of a single target")
+	}
+	if len(schemaFiles) == 0 {
+		var err error
+		schemaFiles, err = SchemaFiles(fsys, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ir.Build(cfg, internalparser.NewWithReader(fsys.ReadFile), schemaFiles)
+}
+
+// runPlugins feeds the IR of every schema in the target to each plugin
+// and collects the files they produce. Plugins always see the whole
+// target, even when only some schemas are being regenerated.
+func (g *generator) runPlugins(ctx context.Context) ([]File, error) {
+	if len(g.cfg.Plugins) == 0 {
+		return nil, nil
+	}
+
+	schemaFiles, err := SchemaFiles(g.fsys, g.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ir.Build(g.cfg, g.parser, schemaFiles)
+	if err != nil {
+		return nil, fmt.Errorf("building IR: %w", err)
+	}
+
+	var files []File
+	for _, name := range g.cfg.Plugins {
+		out, err := plugin.Run(ctx, name, &plugin.Request{Version: ir.Version, IR: doc})
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", name, err)
+		}
+
+		for _, pf := range out {
+			f := File{
+				Path:    pf.Path,
+				Content: []byte(pf.Content),
+				Emitter: "plugin:" + name,
+			}
+			if strings.HasSuffix(f.Path, ".go") {
+				if err := f.format(); err != nil {
+					return nil, err
+				}
+			}
+			files = append(files, f)
+		}
+	}
+
+	return files, nil
+}
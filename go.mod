@@ -2,4 +2,11 @@ module github.com/borderlesshq/restgen
 
 go 1.23.0
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/bmatcuk/doublestar/v4 v4.7.1
+	golang.org/x/mod v0.22.0
+	golang.org/x/tools v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sync v0.10.0 // indirect
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/importer"
+)
+
+// runImport dispatches `restgen import <source>`.
+func runImport(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "go":
+			return runImportGo(args[1:])
+		case "openapi":
+			return runImportOpenAPI(args[1:])
+		}
+	}
+	return errors.New("usage: restgen import go|openapi [flags] ...")
+}
+
+// runImportGo writes SDL for the structs, enums and chi routes of existing
+// Go packages.
+func runImportGo(args []string) error {
+	fs := flag.NewFlagSet("import go", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	output := fs.String("o", "", "directory to write one .sdl per package to (default: stdout)")
+	force := fs.Bool("force", false, "overwrite existing .sdl files")
+	bind := fs.Bool("bind", false, "add @goModel to every block so the existing structs are reused")
+	inputs := fs.String("inputs", "Input,Request", "comma-separated struct name suffixes that become inputs")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return errors.New("usage: restgen import go [-c config.yaml] [-o dir] [-force] [-bind] [-inputs suffixes] packages...")
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return err
+	}
+
+	res, err := importer.FromGo(fs.Args(), importer.GoOptions{
+		Scalars:       cfg.Scalars,
+		InputSuffixes: strings.Split(*inputs, ","),
+		Bind:          *bind,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range res.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	return writeImported(res.Files, *output, *force)
+}
+
+// runImportOpenAPI writes SDL for the operations and component schemas of
+// an OpenAPI 3 document.
+func runImportOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("import openapi", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	output := fs.String("o", "", "directory to write the .sdl files to (default: stdout)")
+	force := fs.Bool("force", false, "overwrite existing .sdl files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: restgen import openapi [-c config.yaml] [-o dir] [-force] spec.yaml")
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := importer.FromOpenAPI(fs.Arg(0), data, importer.OpenAPIOptions{Scalars: cfg.Scalars})
+	if err != nil {
+		return err
+	}
+
+	if err := writeImported(res.Files, *output, *force); err != nil {
+		return err
+	}
+
+	// The report goes last so it isn't lost above the SDL
+	if len(res.Warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d construct(s) could not be represented:\n", len(res.Warnings))
+		for _, w := range res.Warnings {
+			fmt.Fprintf(os.Stderr, "  %s\n", w)
+		}
+	}
+	return nil
+}
+
+// writeImported writes imported SDL files to dir, or to stdout when dir is
+// empty.
+func writeImported(files []importer.File, dir string, force bool) error {
+	if dir == "" {
+		for i, f := range files {
+			if i > 0 {
+				fmt.Println()
+			}
+			if len(files) > 1 {
+				fmt.Printf("# %s\n", f.Path)
+			}
+			os.Stdout.Write(f.Content)
+		}
+		return nil
+	}
+
+	// Refuse up front so we never leave a half-imported directory
+	if !force {
+		for _, f := range files {
+			path := filepath.Join(dir, f.Path)
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists (use -force to overwrite)", path)
+			}
+		}
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Printf("Created %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultPath is where the cache lives, relative to the working directory.
+const DefaultPath = ".restgen/cache.json"
+
+// formatVersion is bumped whenever the cache layout changes.
+const formatVersion = 1
+
+// Cache records, per schema file (and target), the hash of everything that went into
+// generating it and the hash of every file it produced. A schema whose
+// inputs and outputs both still match can be skipped entirely.
+type Cache struct {
+	Version int              `json:"version"`
+	Schemas map[string]Entry `json:"schemas"`
+}
+
+// Entry is the cached state of a single schema file.
+type Entry struct {
+	Key      string            `json:"key"`      // hash of SDL, includes, config and restgen version
+	Includes []string          `json:"includes"` // transitively included SDL files
+	Outputs  map[string]string `json:"outputs"`  // output path -> content hash
+}
+
+// Load reads the cache at path. A missing or unreadable cache is empty.
+func Load(path string) *Cache {
+	c := &Cache{Version: formatVersion, Schemas: make(map[string]Entry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+
+	var loaded Cache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Version != formatVersion || loaded.Schemas == nil {
+		return c
+	}
+
+	return &loaded
+}
+
+// Save writes the cache to path, creating its directory if needed.
+func (c *Cache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Key hashes a schema file together with the given includes and any extra
+// inputs (config, version). Files that can't be read hash as missing, so
+// the key changes if they come back.
+func Key(schemaFile string, includes []string, extra ...[]byte) string {
+	h := sha256.New()
+
+	files := append([]string{schemaFile}, includes...)
+	for _, f := range files {
+		fmt.Fprintf(h, "file %s\n", f)
+		data, err := os.ReadFile(f)
+		if err != nil {
+			fmt.Fprintf(h, "missing\n")
+			continue
+		}
+		fmt.Fprintf(h, "%d\n", len(data))
+		h.Write(data)
+	}
+
+	for _, e := range extra {
+		fmt.Fprintf(h, "extra %d\n", len(e))
+		h.Write(e)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Fresh reports whether schemaFile can be skipped for target (empty
+// without targets): its key matches the cached one, computed over the
+// includes recorded last time, and every recorded output is still on disk
+// with the same content.
+func (c *Cache) Fresh(target, schemaFile string, extra ...[]byte) bool {
+	entry, ok := c.Schemas[entryName(target, schemaFile)]
+	if !ok {
+		return false
+	}
+
+	if Key(schemaFile, entry.Includes, extra...) != entry.Key {
+		return false
+	}
+
+	for path, hash := range entry.Outputs {
+		data, err := os.ReadFile(path)
+		if err != nil || Hash(data) != hash {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Update records the state of schemaFile in target after it was generated.
+func (c *Cache) Update(target, schemaFile string, includes []string, outputs map[string][]byte, extra ...[]byte) {
+	sorted := make([]string, len(includes))
+	copy(sorted, includes)
+	sort.Strings(sorted)
+
+	entry := Entry{
+		Key:      Key(schemaFile, sorted, extra...),
+		Includes: sorted,
+		Outputs:  make(map[string]string, len(outputs)),
+	}
+	for path, content := range outputs {
+		entry.Outputs[path] = Hash(content)
+	}
+
+	c.Schemas[entryName(target, schemaFile)] = entry
+}
+
+// entryName is the key of a schema's entry. The same schema file can be
+// generated by several targets, each with its own outputs.
+func entryName(target, schemaFile string) string {
+	if target == "" {
+		return schemaFile
+	}
+	return target + ":" + schemaFile
+}
+
+// Hash returns the hex SHA-256 of data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+func TestFresh(t *testing.T) {
+	configJSON := func(t *testing.T, edit func(c *config.Config)) []byte {
+		t.Helper()
+		c := config.DefaultConfig()
+		if edit != nil {
+			edit(c)
+		}
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name string
+		// change runs after Update; it returns the target and extra inputs
+		// Fresh is called with
+		change    func(t *testing.T, dir string) (string, [][]byte)
+		wantFresh bool
+	}{
+		{
+			name: "unchanged",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				return "", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+			wantFresh: true,
+		},
+		{
+			name: "config changed",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				return "", [][]byte{configJSON(t, func(c *config.Config) { c.Router = config.RouterStdlib }), []byte("v1")}
+			},
+		},
+		{
+			name: "scalar mapping changed",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				return "", [][]byte{configJSON(t, func(c *config.Config) { c.Scalars["ID"] = config.ScalarFromString("int64") }), []byte("v1")}
+			},
+		},
+		{
+			name: "version changed",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				return "", [][]byte{configJSON(t, nil), []byte("v2")}
+			},
+		},
+		{
+			name: "other target",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				return "admin", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+		},
+		{
+			name: "schema edited",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				write(t, filepath.Join(dir, "contacts.sdl"), "type Contact {\n    name: String!\n}\n")
+				return "", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+		},
+		{
+			name: "include edited",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				write(t, filepath.Join(dir, "geo.sdl"), "type Location {\n    lng: Float!\n}\n")
+				return "", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+		},
+		{
+			name: "output edited",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				write(t, filepath.Join(dir, "contacts_routes.go"), "package routes // edited\n")
+				return "", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+		},
+		{
+			name: "output deleted",
+			change: func(t *testing.T, dir string) (string, [][]byte) {
+				if err := os.Remove(filepath.Join(dir, "contacts_routes.go")); err != nil {
+					t.Fatal(err)
+				}
+				return "", [][]byte{configJSON(t, nil), []byte("v1")}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			schemaFile := filepath.Join(dir, "contacts.sdl")
+			include := filepath.Join(dir, "geo.sdl")
+			output := filepath.Join(dir, "contacts_routes.go")
+			write(t, schemaFile, "@include(\"geo.sdl\")\n\ntype Contact {\n    id: ID!\n}\n")
+			write(t, include, "type Location {\n    lat: Float!\n}\n")
+			write(t, output, "package routes\n")
+
+			c := Load(filepath.Join(dir, "missing.json"))
+			c.Update("", schemaFile, []string{include}, map[string][]byte{output: []byte("package routes\n")},
+				configJSON(t, nil), []byte("v1"))
+
+			// The entry survives a save and load
+			cachePath := filepath.Join(dir, ".restgen", "cache.json")
+			if err := c.Save(cachePath); err != nil {
+				t.Fatal(err)
+			}
+			c = Load(cachePath)
+
+			target, extra := tt.change(t, dir)
+			if got := c.Fresh(target, schemaFile, extra...); got != tt.wantFresh {
+				t.Errorf("Fresh = %v, want %v", got, tt.wantFresh)
+			}
+		})
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,23 +1,92 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 
+	"github.com/borderlesshq/restgen/internal/schema"
 	"gopkg.in/yaml.v3"
 )
 
+// Router flavours the routes emitter can target.
+const (
+	RouterChi    = "chi"    // github.com/go-chi/chi/v5 (default)
+	RouterStdlib = "stdlib" // net/http ServeMux with Go 1.22 patterns
+)
+
 // Config represents the restgen.yaml configuration.
 type Config struct {
-	Package string            `yaml:"package"` // output package name (e.g., "routes")
-	Output  string            `yaml:"output"`  // output directory (e.g., "./routes")
-	Models  ModelsConfig      `yaml:"models"`  // default models package config
-	Scalars map[string]string `yaml:"scalars"` // scalar type mappings
-	Schemas []string          `yaml:"schemas"` // glob patterns for schema files
+	Package   string            `yaml:"package"`   // output package name (e.g., "routes")
+	Output    string            `yaml:"output"`    // output directory (e.g., "./routes")
+	Models    ModelsConfig      `yaml:"models"`    // default models package config
+	Router    string            `yaml:"router"`    // router flavour: "chi" or "stdlib"
+	Scalars   map[string]Scalar `yaml:"scalars"`   // scalar type mappings
+	Schemas   []string          `yaml:"schemas"`   // glob patterns for schema files (** matches any depth)
+	Exclude   []string          `yaml:"exclude"`   // glob patterns of schema files to skip
+	Plugins   []string          `yaml:"plugins"`   // external generators fed the IR on stdin
+	Templates string            `yaml:"templates"` // directory of templates overriding the built-in ones
+	Targets   []Target          `yaml:"targets"`   // independent outputs, each with its own schemas
+	Lint      LintConfig        `yaml:"lint"`      // restgen lint settings
+
+	// Name is the target this config was resolved from (see Resolve).
+	// It is empty for configs without targets.
+	Name string `yaml:"-"`
+
+	// ModelsOverride is set by Resolve for a target with its own
+	// models.package, which then replaces @models in its schemas.
+	ModelsOverride bool `yaml:"-"`
+}
+
+// ModelsFor returns the models package for a schema or include whose
+// @models is sdl: the default package when it has none, and the target's
+// package when the target overrides it.
+func (c *Config) ModelsFor(sdl string) string {
+	if sdl == "" || c.ModelsOverride {
+		return c.Models.Package
+	}
+	return sdl
 }
 
 // ModelsConfig specifies the default models package.
 type ModelsConfig struct {
 	Package string `yaml:"package"` // e.g., "github.com/yourorg/yourapp/models"
+
+	// Dir is where its types are written. It defaults to the package's
+	// directory in the go.mod or go.work module, or its last path segment
+	// outside a module.
+	Dir string `yaml:"dir"`
+}
+
+// Lint severities. SeverityOff disables a rule.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityOff     = "off"
+)
+
+// LintConfig configures restgen lint.
+type LintConfig struct {
+	// Rules overrides the severity of rules by ID (e.g., "input-suffix: off").
+	// Rules not listed run at their default severity. IDs and severities
+	// are checked by lint.Run, which knows the rules.
+	Rules map[string]string `yaml:"rules"`
+}
+
+// Target is one output of a multi-target config, e.g. a public and an
+// admin API generated into separate packages. Unset fields are inherited
+// from the top level; Scalars are merged over the top-level mappings.
+type Target struct {
+	Name    string            `yaml:"name"`
+	Schemas []string          `yaml:"schemas"`
+	Exclude []string          `yaml:"exclude"` // added to the top-level exclude patterns
+	Package string            `yaml:"package"`
+	Output  string            `yaml:"output"`
+	Models  ModelsConfig      `yaml:"models"`
+	Router  string            `yaml:"router"`
+	Scalars map[string]Scalar `yaml:"scalars"`
 }
 
 // DefaultConfig returns a config with sensible defaults.
@@ -28,34 +97,67 @@ func DefaultConfig() *Config {
 		Models: ModelsConfig{
 			Package: "",
 		},
-		Scalars: map[string]string{
-			"ID":      "string",
-			"String":  "string",
-			"Int":     "int",
-			"Float":   "float64",
-			"Boolean": "bool",
-			"Time":    "time.Time",
+		Scalars: map[string]Scalar{
+			"ID":      {Type: "string"},
+			"String":  {Type: "string"},
+			"Int":     {Type: "int"},
+			"Float":   {Type: "float64"},
+			"Boolean": {Type: "bool"},
+			"Time":    {Type: "Time", Import: "time"},
 		},
 		Schemas: []string{"./schemas/*.sdl"},
+		Router:  RouterChi,
 	}
 }
 
-// Load reads configuration from a YAML file.
+// Load reads configuration from a YAML file. Unknown keys are errors, and
+// ${VAR} references are replaced with environment variables first.
 func Load(path string) (*Config, error) {
-	cfg := DefaultConfig()
-
 	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil // use defaults if no config
-		}
 		return nil, err
 	}
+	return Parse(path, data)
+}
+
+// LoadOrDefault is Load, but returns the defaults if path doesn't exist.
+func LoadOrDefault(path string) (*Config, error) {
+	cfg, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+	return cfg, err
+}
+
+// Parse parses configuration from YAML. name is used in error messages,
+// which point at the offending line (e.g., "restgen.yaml:3: unknown key").
+func Parse(name string, data []byte) (*Config, error) {
+	cfg := DefaultConfig()
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+	// Environment variables are substituted into parsed values, not the
+	// text, so they can't change the document's structure
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, decodeError(name, err)
+	}
+	if err := interpolate(name, &doc); err != nil {
 		return nil, err
 	}
 
+	if doc.Kind != 0 {
+		errs := unknownKeys(data)
+		if err := doc.Decode(cfg); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				return nil, decodeError(name, err)
+			}
+			errs = append(errs, typeErr.Errors...)
+		}
+		if len(errs) > 0 {
+			return nil, decodeError(name, &yaml.TypeError{Errors: errs})
+		}
+	}
+
 	// Ensure scalars have defaults
 	if cfg.Scalars == nil {
 		cfg.Scalars = DefaultConfig().Scalars
@@ -68,14 +170,122 @@ func Load(path string) (*Config, error) {
 		}
 	}
 
+	if _, err := cfg.Resolve(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
 	return cfg, nil
 }
 
+// Resolve returns one config per target, with inherited settings filled
+// in. A config without targets resolves to itself.
+func (c *Config) Resolve() ([]*Config, error) {
+	if len(c.Targets) == 0 {
+		if err := validateRouter(c.Router); err != nil {
+			return nil, err
+		}
+		return []*Config{c}, nil
+	}
+
+	seen := make(map[string]bool)
+	var resolved []*Config
+	for i, t := range c.Targets {
+		if t.Name == "" {
+			return nil, fmt.Errorf("targets[%d]: name is required", i)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("targets[%d]: duplicate target name %q", i, t.Name)
+		}
+		seen[t.Name] = true
+
+		rc := &Config{
+			Package:   firstNonEmpty(t.Package, c.Package),
+			Output:    firstNonEmpty(t.Output, c.Output),
+			Models:    c.Models,
+			Router:    firstNonEmpty(t.Router, c.Router),
+			Scalars:   make(map[string]Scalar, len(c.Scalars)+len(t.Scalars)),
+			Schemas:   t.Schemas,
+			Exclude:   append(append([]string(nil), c.Exclude...), t.Exclude...),
+			Plugins:   c.Plugins,
+			Templates: c.Templates,
+			Lint:      c.Lint,
+			Name:      t.Name,
+		}
+		if t.Models.Package != "" {
+			rc.Models = t.Models
+			rc.ModelsOverride = true
+		} else if t.Models.Dir != "" {
+			rc.Models.Dir = t.Models.Dir
+		}
+		if len(rc.Schemas) == 0 {
+			rc.Schemas = c.Schemas
+		}
+		for k, v := range c.Scalars {
+			rc.Scalars[k] = v
+		}
+		for k, v := range t.Scalars {
+			rc.Scalars[k] = v
+		}
+
+		if err := validateRouter(rc.Router); err != nil {
+			return nil, fmt.Errorf("target %s: %w", t.Name, err)
+		}
+
+		resolved = append(resolved, rc)
+	}
+
+	return resolved, nil
+}
+
+func validateRouter(router string) error {
+	switch router {
+	case "", RouterChi, RouterStdlib:
+		return nil
+	}
+	return fmt.Errorf("unknown router %q (want %q or %q)", router, RouterChi, RouterStdlib)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ForSchema returns the config with the scalars declared in s, and in the
+// files it includes, merged over the configured mappings. Declarations in
+// SDL win, and s's own win over its includes'. The config is returned
+// as-is if s declares no scalars.
+func (c *Config) ForSchema(s *schema.Schema) *Config {
+	var defs []schema.ScalarDef
+	for _, inc := range s.Includes {
+		defs = append(defs, inc.Scalars...)
+	}
+	defs = append(defs, s.Scalars...)
+	if len(defs) == 0 {
+		return c
+	}
+
+	sc := *c
+	sc.Scalars = make(map[string]Scalar, len(c.Scalars)+len(defs))
+	for name, mapping := range c.Scalars {
+		sc.Scalars[name] = mapping
+	}
+	for _, def := range defs {
+		mapping := ScalarFromString(def.GoType)
+		mapping.OpenAPIFormat = def.Format
+		sc.Scalars[def.Name] = mapping
+	}
+	return &sc
+}
+
 // GoType converts a GraphQL type to a Go type using scalar mappings.
 func (c *Config) GoType(gqlType string, required bool, isList bool) string {
 	goType := gqlType
 	if mapped, ok := c.Scalars[gqlType]; ok {
-		goType = mapped
+		goType = mapped.GoType()
 	}
 
 	if isList {
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scalar maps an SDL scalar to a Go type. In restgen.yaml it is either the
+// Go type as a string ("time.Time", "string") or a mapping:
+//
+//	Decimal:
+//	  type: Decimal
+//	  import: github.com/shopspring/decimal
+//	  parse: decimal.NewFromString
+type Scalar struct {
+	// Type is the Go type. Without a package qualifier it is qualified
+	// with the name of Import ("Decimal" -> "decimal.Decimal").
+	Type string `yaml:"type" json:"type"`
+
+	// Import is the import path of the package declaring Type, if any.
+	Import string `yaml:"import" json:"import,omitempty"`
+
+	// Parse converts a path or query string to the type:
+	// func(string) (T, error), e.g. "uuid.Parse".
+	Parse string `yaml:"parse" json:"parse,omitempty"`
+
+	// OpenAPIFormat is the OpenAPI string format of the scalar
+	// (e.g., "email", "uuid", "date-time").
+	OpenAPIFormat string `yaml:"openapiFormat" json:"openapiFormat,omitempty"`
+}
+
+// GoType returns the Go type as written in generated code
+// (e.g., "decimal.Decimal").
+func (s Scalar) GoType() string {
+	if s.Import == "" || strings.Contains(s.Type, ".") {
+		return s.Type
+	}
+	return PackageName(s.Import) + "." + s.Type
+}
+
+var (
+	majorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+	gopkgVersionRe = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// PackageName guesses the package name of an import path from its last
+// element, skipping a major version suffix, a gopkg.in style ".vN" suffix
+// and a "go-" prefix: "github.com/go-chi/chi/v5" -> "chi",
+// "gopkg.in/guregu/null.v4" -> "null".
+func PackageName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersionRe.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = gopkgVersionRe.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, ".go")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// UnmarshalYAML accepts either a plain Go type or a mapping.
+func (s *Scalar) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = ScalarFromString(node.Value)
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return typeError(node.Line, "scalar must be a Go type or a mapping with type, import and parse")
+	}
+
+	// Strict like the rest of the config: node.Decode doesn't check keys
+	keys := yamlKeys(reflect.TypeOf(Scalar{}))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !slices.Contains(keys, key.Value) {
+			msg := fmt.Sprintf("unknown key %q", key.Value)
+			if sug := suggest(key.Value, keys); sug != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", sug)
+			}
+			return typeError(key.Line, msg)
+		}
+	}
+
+	type plain Scalar
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	if p.Type == "" {
+		return typeError(node.Line, "scalar mapping needs a type")
+	}
+	*s = Scalar(p)
+	return nil
+}
+
+// typeError reports a config error the way yaml.v3 does, so it is
+// collected with the others and formatted by decodeError.
+func typeError(line int, msg string) error {
+	return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %s", line, msg)}}
+}
+
+// ScalarFromString parses the short form of a mapping: a Go type such as
+// "string" or "time.Time", or one qualified with its full import path such
+// as "github.com/shopspring/decimal.Decimal". Well-known standard library
+// packages get their import path.
+func ScalarFromString(goType string) Scalar {
+	if slash := strings.LastIndex(goType, "/"); slash >= 0 {
+		if dot := strings.LastIndex(goType, "."); dot > slash {
+			return Scalar{Type: goType[dot+1:], Import: goType[:dot]}
+		}
+	}
+
+	s := Scalar{Type: goType}
+	if pkg, _, ok := strings.Cut(goType, "."); ok {
+		if imp, ok := stdlibImports[pkg]; ok {
+			s.Import = imp
+		}
+	}
+	return s
+}
+
+// stdlibImports are the standard library packages a short-form scalar
+// mapping can refer to without spelling out the import.
+var stdlibImports = map[string]string{
+	"time":  "time",
+	"json":  "encoding/json",
+	"big":   "math/big",
+	"netip": "net/netip",
+	"url":   "net/url",
+	"net":   "net",
+	"mail":  "net/mail",
+}
@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// JSONSchema is the JSON Schema for restgen.yaml, for editor completion
+// and validation. Keep it in sync with Config.
+//
+//go:embed restgen.schema.json
+var JSONSchema []byte
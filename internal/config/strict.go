@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var envRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolate replaces ${VAR} in the scalar values under n with the value
+// of the environment variable VAR, and ${VAR:-default} with default when
+// VAR is unset or empty. An unset variable without a default is an error.
+// Keys and comments are left alone, and a value never changes the
+// structure of the document.
+func interpolate(name string, n *yaml.Node) error {
+	var errs []string
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c)
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(n.Content); i += 2 {
+				walk(n.Content[i])
+			}
+		case yaml.ScalarNode:
+			value := envRe.ReplaceAllStringFunc(n.Value, func(ref string) string {
+				m := envRe.FindStringSubmatch(ref)
+				value, ok := os.LookupEnv(m[1])
+				if ok && value != "" {
+					return value
+				}
+				if strings.Contains(ref, ":-") {
+					return m[2]
+				}
+				if ok {
+					return ""
+				}
+				errs = append(errs, fmt.Sprintf("%s:%d: environment variable %s is not set", name, n.Line, m[1]))
+				return ref
+			})
+			if value != n.Value {
+				n.Value = value
+				// Let a plain value such as ${PORT} resolve to a number
+				if n.Style == 0 {
+					n.Tag = ""
+				}
+			}
+		}
+	}
+	walk(n)
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// unknownKeys decodes data strictly into a throwaway Config and returns
+// only the unknown key errors, which decoding a yaml.Node can't report.
+func unknownKeys(data []byte) []string {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var typeErr *yaml.TypeError
+	if err := dec.Decode(DefaultConfig()); !errors.As(err, &typeErr) {
+		return nil
+	}
+	var msgs []string
+	for _, msg := range typeErr.Errors {
+		if unknownFieldRe.MatchString(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+var (
+	unknownFieldRe = regexp.MustCompile(`^line (\d+): field (\S+) not found in type (\S+)$`)
+	lineRe         = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// keyedTypes are the config types whose YAML keys are suggested when an
+// unknown key is found, by the type name yaml.v3 reports.
+var keyedTypes = map[string]reflect.Type{
+	"config.Config":       reflect.TypeOf(Config{}),
+	"config.ModelsConfig": reflect.TypeOf(ModelsConfig{}),
+	"config.Target":       reflect.TypeOf(Target{}),
+	"config.LintConfig":   reflect.TypeOf(LintConfig{}),
+}
+
+// decodeError rewrites yaml.v3 errors as "name:line: message", turning
+// unknown fields into "unknown key" errors with a suggestion.
+func decodeError(name string, err error) error {
+	var msgs []string
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	var out []string
+	for _, msg := range msgs {
+		if m := unknownFieldRe.FindStringSubmatch(msg); m != nil {
+			line := fmt.Sprintf("%s:%s: unknown key %q", name, m[1], m[2])
+			if t, ok := keyedTypes[m[3]]; ok {
+				if s := suggest(m[2], yamlKeys(t)); s != "" {
+					line += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+			}
+			out = append(out, line)
+			continue
+		}
+		if m := lineRe.FindStringSubmatch(msg); m != nil {
+			out = append(out, fmt.Sprintf("%s:%s: %s", name, m[1], m[2]))
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s: %s", name, strings.TrimPrefix(msg, "yaml: ")))
+	}
+
+	return errors.New(strings.Join(out, "\n"))
+}
+
+// yamlKeys returns the YAML keys of struct type t.
+func yamlKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			keys = append(keys, tag)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// suggest returns the key closest to key, if any is close enough to be a
+// likely typo.
+func suggest(key string, keys []string) string {
+	best, bestDist := "", 3
+	for _, k := range keys {
+		if d := distance(strings.ToLower(key), k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,334 @@
+// Package diff compares two versions of a set of schemas and classifies
+// every change by whether it can break existing clients.
+package diff
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/ir"
+)
+
+// Severity says whether a change can break existing clients.
+type Severity string
+
+const (
+	Breaking    Severity = "breaking"
+	NonBreaking Severity = "non-breaking"
+)
+
+// Change is a single difference between the old and new schemas.
+type Change struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`   // e.g., "call-removed", "input-field-required"
+	Schema   string   `json:"schema"` // schema file, relative to the compared root
+	Node     string   `json:"node"`   // e.g., "call getContact", "input CreateContactInput.email"
+	Message  string   `json:"message"`
+	Pos      *ir.Pos  `json:"pos,omitempty"` // in the new schema, or the old one for removals
+}
+
+// Compare returns the changes between old and new, which are keyed by
+// schema file. Changes are grouped by schema, in file order.
+func Compare(old, new map[string]ir.Schema) []Change {
+	var files []string
+	for f := range old {
+		files = append(files, f)
+	}
+	for f := range new {
+		if _, ok := old[f]; !ok {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+
+	var changes []Change
+	for _, f := range files {
+		o, inOld := old[f]
+		n, inNew := new[f]
+		d := &differ{file: f}
+		switch {
+		case !inNew:
+			d.compare(&o, &ir.Schema{File: f})
+		case !inOld:
+			d.compare(&ir.Schema{File: f}, &n)
+		default:
+			d.compare(&o, &n)
+		}
+		changes = append(changes, d.changes...)
+	}
+	return changes
+}
+
+// HasBreaking reports whether any change is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, c := range changes {
+		if c.Severity == Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+type differ struct {
+	file    string
+	changes []Change
+}
+
+func (d *differ) add(sev Severity, kind, node string, pos ir.Pos, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Severity: sev,
+		Kind:     kind,
+		Schema:   d.file,
+		Node:     node,
+		Message:  fmt.Sprintf(format, args...),
+		Pos:      &pos,
+	})
+}
+
+func (d *differ) compare(old, new *ir.Schema) {
+	d.compareCalls(old, new)
+	d.compareTypes("type", old.Types, new.Types)
+	d.compareTypes("input", old.Inputs, new.Inputs)
+	d.compareEnums(old.Enums, new.Enums)
+}
+
+// route is the full path of a call, base included.
+func route(s *ir.Schema, c ir.Call) string {
+	return path.Clean(s.Base + "/" + c.Path)
+}
+
+func (d *differ) compareCalls(old, new *ir.Schema) {
+	newCalls := make(map[string]ir.Call)
+	for _, c := range new.Calls {
+		newCalls[c.Name] = c
+	}
+	oldCalls := make(map[string]bool)
+
+	for _, oc := range old.Calls {
+		oldCalls[oc.Name] = true
+		node := "call " + oc.Name
+		nc, ok := newCalls[oc.Name]
+		if !ok {
+			d.add(Breaking, "call-removed", node, oc.Pos, "%s %s was removed", oc.Method, route(old, oc))
+			continue
+		}
+
+		if oc.Method != nc.Method {
+			d.add(Breaking, "call-method-changed", node, nc.Pos, "method changed from %s to %s", oc.Method, nc.Method)
+		}
+		if op, np := route(old, oc), route(new, nc); op != np {
+			d.add(Breaking, "call-path-changed", node, nc.Pos, "path changed from %s to %s", op, np)
+		}
+
+		d.compareArgs(node, oc, nc)
+
+		or, nr := oc.Returns, nc.Returns
+		switch {
+		case typeName(or) != typeName(nr) || or.List != nr.List:
+			d.add(Breaking, "call-return-changed", node, nc.Pos, "return type changed from %s to %s", or.String(), nr.String())
+		case or.Required && !nr.Required:
+			d.add(Breaking, "call-return-nullable", node, nc.Pos, "return type %s is now nullable (%s); clients may receive null", or.String(), nr.String())
+		case !or.Required && nr.Required:
+			d.add(Breaking, "call-return-non-null", node, nc.Pos, "return type %s is now non-null (%s); generated clients' types change", or.String(), nr.String())
+		}
+	}
+
+	for _, nc := range new.Calls {
+		if !oldCalls[nc.Name] {
+			d.add(NonBreaking, "call-added", "call "+nc.Name, nc.Pos, "%s %s was added", nc.Method, route(new, nc))
+		}
+	}
+}
+
+func (d *differ) compareArgs(node string, oc, nc ir.Call) {
+	newArgs := make(map[string]ir.Arg)
+	for _, a := range nc.Args {
+		newArgs[a.Name] = a
+	}
+	oldArgs := make(map[string]bool)
+
+	for _, oa := range oc.Args {
+		oldArgs[oa.Name] = true
+		argNode := node + "(" + oa.Name + ")"
+		na, ok := newArgs[oa.Name]
+		if !ok {
+			d.add(Breaking, "arg-removed", argNode, nc.Pos, "%s argument %s was removed", oa.Source, oa.Name)
+			continue
+		}
+		if oa.Source != na.Source {
+			d.add(Breaking, "arg-source-changed", argNode, na.Pos, "argument %s moved from %s to %s", oa.Name, oa.Source, na.Source)
+		}
+		switch {
+		case typeName(oa.Type) != typeName(na.Type) || oa.Type.List != na.Type.List:
+			d.add(Breaking, "arg-type-changed", argNode, na.Pos, "argument %s changed from %s to %s", oa.Name, oa.Type.String(), na.Type.String())
+		case !oa.Type.Required && na.Type.Required:
+			d.add(Breaking, "arg-required", argNode, na.Pos, "argument %s is now required", oa.Name)
+		case oa.Type.Required && !na.Type.Required:
+			d.add(NonBreaking, "arg-optional", argNode, na.Pos, "argument %s is now optional", oa.Name)
+		}
+	}
+
+	for _, na := range nc.Args {
+		if oldArgs[na.Name] {
+			continue
+		}
+		argNode := node + "(" + na.Name + ")"
+		if na.Type.Required {
+			d.add(Breaking, "arg-added-required", argNode, na.Pos, "required %s argument %s was added", na.Source, na.Name)
+		} else {
+			d.add(NonBreaking, "arg-added", argNode, na.Pos, "optional %s argument %s was added", na.Source, na.Name)
+		}
+	}
+}
+
+// compareTypes compares types (kind "type") or inputs (kind "input").
+// Clients read types and write inputs, so nullability changes cut the
+// opposite ways.
+func (d *differ) compareTypes(kind string, old, new []ir.Type) {
+	newTypes := make(map[string]ir.Type)
+	for _, t := range new {
+		newTypes[t.Name] = t
+	}
+	oldTypes := make(map[string]bool)
+
+	for _, ot := range old {
+		oldTypes[ot.Name] = true
+		node := kind + " " + ot.Name
+		nt, ok := newTypes[ot.Name]
+		if !ok {
+			d.add(Breaking, kind+"-removed", node, ot.Pos, "%s %s was removed", kind, ot.Name)
+			continue
+		}
+		if ot.GoModel != nt.GoModel {
+			d.add(Breaking, kind+"-binding-changed", node, nt.Pos, "@goModel changed from %q to %q", ot.GoModel, nt.GoModel)
+		}
+
+		newFields := make(map[string]ir.Field)
+		for _, f := range nt.Fields {
+			newFields[f.Name] = f
+		}
+		oldFields := make(map[string]bool)
+
+		for _, of := range ot.Fields {
+			oldFields[of.Name] = true
+			fieldNode := node + "." + of.Name
+			nf, ok := newFields[of.Name]
+			if !ok {
+				d.add(Breaking, kind+"-field-removed", fieldNode, nt.Pos, "field %s was removed", of.Name)
+				continue
+			}
+			switch {
+			case typeName(of.Type) != typeName(nf.Type) || of.Type.List != nf.Type.List:
+				d.add(Breaking, kind+"-field-type-changed", fieldNode, nf.Pos, "field %s changed from %s to %s", of.Name, of.Type.String(), nf.Type.String())
+			case !of.Type.Required && nf.Type.Required && kind == "input":
+				d.add(Breaking, "input-field-required", fieldNode, nf.Pos, "field %s is now required", of.Name)
+			case !of.Type.Required && nf.Type.Required:
+				d.add(NonBreaking, "type-field-non-null", fieldNode, nf.Pos, "field %s is now non-null", of.Name)
+			case of.Type.Required && !nf.Type.Required && kind == "input":
+				d.add(NonBreaking, "input-field-optional", fieldNode, nf.Pos, "field %s is now optional", of.Name)
+			case of.Type.Required && !nf.Type.Required:
+				d.add(Breaking, "type-field-nullable", fieldNode, nf.Pos, "field %s is now nullable; clients may receive null", of.Name)
+			}
+		}
+
+		for _, nf := range nt.Fields {
+			if oldFields[nf.Name] {
+				continue
+			}
+			fieldNode := node + "." + nf.Name
+			if kind == "input" && nf.Type.Required {
+				d.add(Breaking, "input-field-added-required", fieldNode, nf.Pos, "required field %s was added", nf.Name)
+			} else {
+				d.add(NonBreaking, kind+"-field-added", fieldNode, nf.Pos, "field %s was added", nf.Name)
+			}
+		}
+	}
+
+	for _, nt := range new {
+		if !oldTypes[nt.Name] {
+			d.add(NonBreaking, kind+"-added", kind+" "+nt.Name, nt.Pos, "%s %s was added", kind, nt.Name)
+		}
+	}
+}
+
+func (d *differ) compareEnums(old, new []ir.Enum) {
+	newEnums := make(map[string]ir.Enum)
+	for _, e := range new {
+		newEnums[e.Name] = e
+	}
+	oldEnums := make(map[string]bool)
+
+	for _, oe := range old {
+		oldEnums[oe.Name] = true
+		node := "enum " + oe.Name
+		ne, ok := newEnums[oe.Name]
+		if !ok {
+			d.add(Breaking, "enum-removed", node, oe.Pos, "enum %s was removed", oe.Name)
+			continue
+		}
+
+		newValues := make(map[string]bool)
+		for _, v := range ne.Values {
+			newValues[v] = true
+		}
+		oldValues := make(map[string]bool)
+		for _, v := range oe.Values {
+			oldValues[v] = true
+			if !newValues[v] {
+				d.add(Breaking, "enum-value-removed", node+"."+v, ne.Pos, "value %s was removed", v)
+			}
+		}
+		for _, v := range ne.Values {
+			if !oldValues[v] {
+				d.add(NonBreaking, "enum-value-added", node+"."+v, ne.Pos, "value %s was added", v)
+			}
+		}
+	}
+
+	for _, ne := range new {
+		if !oldEnums[ne.Name] {
+			d.add(NonBreaking, "enum-added", "enum "+ne.Name, ne.Pos, "enum %s was added", ne.Name)
+		}
+	}
+}
+
+func typeName(t ir.TypeRef) string {
+	if t.Namespace != "" {
+		return t.Namespace + "." + t.Name
+	}
+	return t.Name
+}
+
+// Text renders changes for a terminal, breaking changes first.
+func Text(changes []Change) string {
+	if len(changes) == 0 {
+		return "No changes.\n"
+	}
+
+	var b strings.Builder
+	for _, sev := range []Severity{Breaking, NonBreaking} {
+		var group []Change
+		for _, c := range changes {
+			if c.Severity == sev {
+				group = append(group, c)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s changes (%d):\n", strings.ToUpper(string(sev[:1]))+string(sev[1:]), len(group))
+		for _, c := range group {
+			loc := c.Schema
+			if c.Pos != nil {
+				loc = fmt.Sprintf("%s:%d:%d", c.Schema, c.Pos.Line, c.Pos.Column)
+			}
+			fmt.Fprintf(&b, "  %s: %s: %s [%s]\n", loc, c.Node, c.Message, c.Kind)
+		}
+	}
+	return b.String()
+}
@@ -2,17 +2,28 @@ package emitter
 
 import (
 	"bytes"
+	"fmt"
 	"text/template"
 )
 
 // DependenciesEmitter generates the dependencies file (only once, never overwritten).
 type DependenciesEmitter struct {
-	pkg string
+	pkg  string
+	tmpl *template.Template
 }
 
-// NewDependenciesEmitter creates a new dependencies emitter.
+// NewDependenciesEmitter creates a new dependencies emitter using the
+// built-in template.
 func NewDependenciesEmitter(pkg string) *DependenciesEmitter {
-	return &DependenciesEmitter{pkg: pkg}
+	return &DependenciesEmitter{
+		pkg:  pkg,
+		tmpl: template.Must(template.New("dependencies").Funcs(FuncMap()).Parse(depsTemplate)),
+	}
+}
+
+// SetTemplate replaces the template. It is executed with *depsTemplateData.
+func (e *DependenciesEmitter) SetTemplate(tmpl *template.Template) {
+	e.tmpl = tmpl
 }
 
 // Emit generates the dependencies file content.
@@ -21,14 +32,9 @@ func (e *DependenciesEmitter) Emit() (string, error) {
 		Package: e.pkg,
 	}
 
-	tmpl, err := template.New("dependencies").Parse(depsTemplate)
-	if err != nil {
-		return "", err
-	}
-
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
+	if err := e.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
 	}
 
 	return buf.String(), nil
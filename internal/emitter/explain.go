@@ -0,0 +1,120 @@
+package emitter
+
+import (
+	"fmt"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// CallBinding describes how the generated handler for a call reads its
+// arguments and what it responds with.
+type CallBinding struct {
+	Call     string       `json:"call"`
+	Handler  string       `json:"handler"` // e.g., "ContactsHandler.UpdateLocation"
+	Method   string       `json:"method"`
+	Path     string       `json:"path"` // as written in the call, without @base
+	Args     []ArgBinding `json:"args"`
+	Response string       `json:"response"` // type parameter of shared.ApiResponse
+}
+
+// ArgBinding is how one argument reaches the handler.
+type ArgBinding struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`   // as written in SDL
+	Source  string `json:"source"` // "path", "query" or "body"
+	Reason  string `json:"reason"` // why it comes from Source
+	GoType  string `json:"goType"`
+	Decoder string `json:"decoder"` // the code that reads it
+}
+
+// Binding explains the handler generated for the call named call in s.
+func (e *RoutesEmitter) Binding(s *schema.Schema, call string) (*CallBinding, error) {
+	e = &RoutesEmitter{cfg: e.cfg.ForSchema(s), tmpl: e.tmpl}
+	data := e.buildTemplateData(s)
+
+	var c *schema.Call
+	var cd *callData
+	for i := range s.Calls {
+		if s.Calls[i].Name == call {
+			c, cd = &s.Calls[i], &data.Calls[i]
+		}
+	}
+	if c == nil {
+		return nil, fmt.Errorf("no call named %s", call)
+	}
+
+	b := &CallBinding{
+		Call:     c.Name,
+		Handler:  data.HandlerName + "Handler." + cd.HandlerName,
+		Method:   c.Method,
+		Path:     c.Path,
+		Args:     []ArgBinding{},
+		Response: cd.GoReturnType,
+	}
+
+	byName := func(args []argData, name string) *argData {
+		for i := range args {
+			if args[i].Name == name {
+				return &args[i]
+			}
+		}
+		return nil
+	}
+
+	for _, a := range c.Args {
+		ab := ArgBinding{Name: a.Name, Type: sdlType(a)}
+		switch {
+		case c.PathParamSet()[a.Name]:
+			pa := byName(cd.PathArgs, a.Name)
+			raw := fmt.Sprintf("chi.URLParam(r, %q)", a.Name)
+			if data.Router == config.RouterStdlib {
+				raw = fmt.Sprintf("r.PathValue(%q)", a.Name)
+			}
+			ab.Source, ab.GoType = "path", pa.GoType
+			ab.Reason = fmt.Sprintf("{%s} appears in the path", a.Name)
+			ab.Decoder = decodeString(raw, pa.Parse, pa.GoType)
+
+		case cd.BodyArg != nil && cd.BodyArg.Name == a.Name:
+			ab.Source, ab.GoType = "body", cd.BodyArg.GoType
+			ab.Reason = fmt.Sprintf("%s calls decode their one non-path argument from the body", c.Method)
+			ab.Decoder = fmt.Sprintf("json.NewDecoder(r.Body).Decode(&%s) (encoding/json)", cd.BodyArg.GoName)
+
+		default:
+			qa := byName(cd.QueryArgs, a.Name)
+			ab.Source, ab.GoType = "query", qa.GoType
+			if qa.IsComplex {
+				ab.Reason = fmt.Sprintf("%s calls read non-path arguments from the query string; %s is a struct, so its fields are the query parameters", c.Method, a.Type)
+				ab.Decoder = fmt.Sprintf("schema.NewDecoder().Decode(&%s, r.URL.Query()) (gorilla/schema)", qa.GoName)
+			} else {
+				ab.Reason = fmt.Sprintf("%s calls read non-path arguments from the query string", c.Method)
+				ab.Decoder = decodeString(fmt.Sprintf("r.URL.Query().Get(%q)", a.Name), qa.Parse, qa.GoType)
+			}
+		}
+		b.Args = append(b.Args, ab)
+	}
+
+	return b, nil
+}
+
+// decodeString describes how a scalar read as a string is converted.
+func decodeString(raw, parse, goType string) string {
+	if parse != "" {
+		return fmt.Sprintf("%s(%s)", parse, raw)
+	}
+	if goType == "string" || goType == "*string" || goType == "[]string" {
+		return raw
+	}
+	return fmt.Sprintf("%s, as a string: the scalar has no parse function to convert it to %s", raw, goType)
+}
+
+func sdlType(a schema.Arg) string {
+	t := a.Type
+	if a.IsList {
+		t = "[" + t + "]"
+	}
+	if a.Required {
+		t += "!"
+	}
+	return t
+}
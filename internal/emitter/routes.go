@@ -3,7 +3,7 @@ package emitter
 import (
 	"bytes"
 	"fmt"
-	"strings"
+	"sort"
 	"text/template"
 
 	"github.com/borderlesshq/restgen/internal/config"
@@ -12,32 +12,33 @@ import (
 
 // RoutesEmitter generates route handler files.
 type RoutesEmitter struct {
-	cfg *config.Config
+	cfg  *config.Config
+	tmpl *template.Template
 }
 
-// NewRoutesEmitter creates a new routes emitter.
+// NewRoutesEmitter creates a new routes emitter using the built-in template.
 func NewRoutesEmitter(cfg *config.Config) *RoutesEmitter {
-	return &RoutesEmitter{cfg: cfg}
+	return &RoutesEmitter{
+		cfg:  cfg,
+		tmpl: template.Must(template.New("routes").Funcs(FuncMap()).Parse(routesTemplate)),
+	}
+}
+
+// SetTemplate replaces the template. It is executed with *templateData.
+func (e *RoutesEmitter) SetTemplate(tmpl *template.Template) {
+	e.tmpl = tmpl
 }
 
 // Emit generates the routes file content for a schema.
 func (e *RoutesEmitter) Emit(s *schema.Schema) (string, error) {
-	data := e.buildTemplateData(s)
+	// Resolve types against the scalars s declares, on a copy so the
+	// emitter can be shared between schemas
+	e = &RoutesEmitter{cfg: e.cfg.ForSchema(s), tmpl: e.tmpl}
 
-	tmpl, err := template.New("routes").Funcs(template.FuncMap{
-		"lower": strings.ToLower,
-		"title": strings.Title,
-		"chiMethod": func(method string) string {
-			// Convert "POST" -> "Post", "GET" -> "Get", etc.
-			return strings.Title(strings.ToLower(method))
-		},
-	}).Parse(routesTemplate)
-	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
-	}
+	data := e.buildTemplateData(s)
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := e.tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
 
@@ -46,6 +47,7 @@ func (e *RoutesEmitter) Emit(s *schema.Schema) (string, error) {
 
 type templateData struct {
 	Package       string
+	Router        string // "chi" or "stdlib"
 	HandlerName   string
 	BasePath      string
 	ModelsPackage string
@@ -66,9 +68,11 @@ type callData struct {
 	HandlerName    string
 	Method         string
 	Path           string
+	Pattern        string // net/http ServeMux pattern (e.g., "GET /{id}")
 	ReturnType     string
 	GoReturnType   string // type for ApiResponse generic param (e.g., "models.Contact" or "*models.Contact")
 	PathParams     []string
+	PathArgs       []argData // path parameters in path order; Type is empty if no arg declares them
 	BodyArg        *argData
 	QueryArgs      []argData
 	ReturnNullable bool // true if return type is nullable (no !)
@@ -79,19 +83,25 @@ type argData struct {
 	GoName    string
 	Type      string
 	GoType    string
-	IsComplex bool // true if this is a struct type needing schema decoder
+	IsComplex bool   // true if this is a struct type needing schema decoder
+	Required  bool   // false for optional query parameters, whose GoType is a pointer
+	Parse     string // scalar's string -> value function (e.g., "uuid.Parse"), if configured
 }
 
 func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
-	// Derive handler name from file name or base path
-	handlerName := deriveHandlerName(s)
+	handlerName := s.HandlerName()
+
+	router := e.cfg.Router
+	if router == "" {
+		router = config.RouterChi
+	}
 
 	// Build imports
-	imports := []importDef{
-		{Path: "net/http"},
-		{Path: "github.com/go-chi/chi/v5"},
-		{Path: "github.com/borderlesshq/restgen/shared"},
+	imports := []importDef{{Path: "net/http"}}
+	if router == config.RouterChi {
+		imports = append(imports, importDef{Path: "github.com/go-chi/chi/v5"})
 	}
+	imports = append(imports, importDef{Path: "github.com/borderlesshq/restgen/shared"})
 
 	modelsAlias := "models"
 	if s.Models != "" {
@@ -101,7 +111,10 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 	// Build include aliases and add imports for included SDLs
 	includeAliases := make(map[string]string)
 	for _, inc := range s.Includes {
-		if inc.Models != "" {
+		if inc.Models != "" && inc.Models == s.Models {
+			// Generated into the same package as this schema's models
+			includeAliases[inc.Namespace] = modelsAlias
+		} else if inc.Models != "" {
 			// Use namespace as alias (e.g., geo_models)
 			alias := inc.Namespace
 			includeAliases[inc.Namespace] = alias
@@ -132,8 +145,21 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 		imports = append(imports, importDef{Path: "github.com/gorilla/schema"})
 	}
 
+	// Imports needed by scalar and bound types that appear in generated code
+	scalarImports := make(map[string]bool)
+
 	// Helper to resolve type to Go type with proper package alias
 	resolveGoType := func(typeRef string) string {
+		// Bound type: github.com/acme/money.Amount -> money.Amount, or
+		// models.Amount when bound into this schema's models package
+		if gm := s.GoModel(typeRef); gm != "" {
+			goType, imp := boundType(gm, s.Models, modelsAlias)
+			if imp != "" {
+				scalarImports[imp] = true
+			}
+			return goType
+		}
+
 		ns, typeName := schema.ParseTypeRef(typeRef)
 		if ns != "" {
 			// Namespaced type: geo.Location -> geo_models.Location
@@ -144,7 +170,10 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 			return ns + "." + typeName
 		}
 		// Local type
-		if _, isScalar := e.cfg.Scalars[typeName]; isScalar {
+		if scalar, isScalar := e.cfg.Scalars[typeName]; isScalar {
+			if scalar.Import != "" {
+				scalarImports[scalar.Import] = true
+			}
 			return e.cfg.GoType(typeName, true, false)
 		}
 		return modelsAlias + "." + typeName
@@ -168,12 +197,32 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 			HandlerName:    c.HandlerName(),
 			Method:         c.Method,
 			Path:           c.Path,
+			Pattern:        c.Method + " " + servePattern(c.Path),
 			ReturnType:     c.ReturnType,
 			GoReturnType:   goReturnType,
 			PathParams:     c.PathParams(),
 			ReturnNullable: returnNullable,
 		}
 
+		argsByName := make(map[string]schema.Arg)
+		for _, a := range c.Args {
+			argsByName[a.Name] = a
+		}
+		for _, name := range cd.PathParams {
+			pa := argData{Name: name, GoName: name, Required: true}
+			if a, ok := argsByName[name]; ok {
+				scalar := e.cfg.Scalars[a.Type]
+				pa.Type = a.Type
+				pa.GoType = e.cfg.GoType(a.Type, true, false)
+				pa.Parse = scalar.Parse
+				// The parse function usually lives in the scalar's package
+				if scalar.Parse != "" && scalar.Import != "" {
+					scalarImports[scalar.Import] = true
+				}
+			}
+			cd.PathArgs = append(cd.PathArgs, pa)
+		}
+
 		if body := c.BodyArg(); body != nil {
 			bodyGoType := resolveGoType(body.Type)
 			if body.IsList {
@@ -194,20 +243,40 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 				goType = resolveGoType(qa.Type)
 			}
 
+			// A list can't be parsed from a single value
+			var parse string
+			if scalar := e.cfg.Scalars[qa.Type]; !qa.IsList && scalar.Parse != "" {
+				parse = scalar.Parse
+				if scalar.Import != "" {
+					scalarImports[scalar.Import] = true
+				}
+			}
 			cd.QueryArgs = append(cd.QueryArgs, argData{
 				Name:      qa.Name,
 				GoName:    qa.Name,
 				Type:      qa.Type,
 				GoType:    goType,
 				IsComplex: isComplex,
+				Required:  qa.Required,
+				Parse:     parse,
 			})
 		}
 
 		calls = append(calls, cd)
 	}
 
+	var extraImports []string
+	for imp := range scalarImports {
+		extraImports = append(extraImports, imp)
+	}
+	sort.Strings(extraImports)
+	for _, imp := range extraImports {
+		imports = append(imports, importDef{Path: imp})
+	}
+
 	return &templateData{
 		Package:        e.cfg.Package,
+		Router:         router,
 		HandlerName:    handlerName,
 		BasePath:       s.Base,
 		ModelsPackage:  s.Models,
@@ -218,52 +287,21 @@ func (e *RoutesEmitter) buildTemplateData(s *schema.Schema) *templateData {
 	}
 }
 
+// servePattern converts a route path to a ServeMux path. "/" would match
+// every path in a ServeMux, so it becomes "/{$}" to match only the root.
+func servePattern(path string) string {
+	if path == "/" {
+		return "/{$}"
+	}
+	return path
+}
+
 // isComplexType returns true if the type is a struct (not a scalar).
 func (e *RoutesEmitter) isComplexType(typeName string) bool {
 	_, isScalar := e.cfg.Scalars[typeName]
 	return !isScalar
 }
 
-func deriveHandlerName(s *schema.Schema) string {
-	// From file name: contacts.sdl -> Contacts, business_locations.sdl -> BusinessLocations
-	if s.FileName != "" {
-		name := strings.TrimSuffix(s.FileName, ".sdl")
-		if len(name) > 0 {
-			return toPascalCase(name)
-		}
-	}
-
-	// From base path: /v1/contacts -> Contacts
-	if s.Base != "" {
-		parts := strings.Split(strings.Trim(s.Base, "/"), "/")
-		if len(parts) > 0 {
-			name := parts[len(parts)-1]
-			return toPascalCase(name)
-		}
-	}
-
-	return "Handler"
-}
-
-// toPascalCase converts snake_case or kebab-case to PascalCase.
-// e.g., "business_locations" -> "BusinessLocations", "my-handler" -> "MyHandler"
-func toPascalCase(s string) string {
-	// Replace hyphens with underscores for uniform handling
-	s = strings.ReplaceAll(s, "-", "_")
-
-	parts := strings.Split(s, "_")
-	var result strings.Builder
-	for _, part := range parts {
-		if len(part) > 0 {
-			result.WriteString(strings.ToUpper(part[:1]))
-			if len(part) > 1 {
-				result.WriteString(part[1:])
-			}
-		}
-	}
-	return result.String()
-}
-
 var routesTemplate = `// Code generated by restgen. DO NOT EDIT ABOVE THE MARKER.
 
 package {{.Package}}
@@ -301,6 +339,29 @@ func (h *{{.HandlerName}}Handler) BasePath() string {
 	return "{{.BasePath}}"
 }
 
+{{- if eq .Router "stdlib"}}
+
+func (h *{{.HandlerName}}Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+{{- range .Calls}}
+	mux.HandleFunc("{{.Pattern}}", h.{{.HandlerName}})
+{{- end}}
+
+	return h.applyMiddleware(mux)
+}
+
+// ============================================================================
+// MIDDLEWARE (add your middleware here)
+// ============================================================================
+
+func (h *{{.HandlerName}}Handler) applyMiddleware(next http.Handler) http.Handler {
+	// Example:
+	// return middleware.RequestID(middleware.Logger(next))
+	return next
+}
+{{- else}}
+
 func (h *{{.HandlerName}}Handler) Routes() chi.Router {
 	r := chi.NewRouter()
 	h.applyMiddleware(r)
@@ -323,9 +384,15 @@ func (h *{{.HandlerName}}Handler) applyMiddleware(r chi.Router) {
 	//
 	// Per-route middleware can be applied in Routes() using r.With(...)
 }
+{{- end}}
 
 // RouteMiddleware returns middleware for specific routes.
+{{- if eq .Router "stdlib"}}
+// This is for documentation/introspection; apply it by wrapping handlers in
+// Routes(), e.g. mux.Handle("GET /{id}", cacheMiddleware(http.HandlerFunc(h.Get))).
+{{- else}}
 // This is for documentation/introspection; apply via r.With() in Routes().
+{{- end}}
 func (h *{{.HandlerName}}Handler) RouteMiddleware() map[string][]func(http.Handler) http.Handler {
 	return map[string][]func(http.Handler) http.Handler{
 		// "POST /": {rateLimiter},
@@ -341,10 +408,24 @@ func (h *{{.HandlerName}}Handler) RouteMiddleware() map[string][]func(http.Handl
 {{range .Calls}}
 
 func (h *{{$.HandlerName}}Handler) {{.HandlerName}}(w http.ResponseWriter, r *http.Request) {
+{{- $returnType := .GoReturnType}}
 {{- if .PathParams}}
 	// Path parameters:
-{{- range .PathParams}}
-	 // {{.}} := chi.URLParam(r, "{{.}}")
+{{- range .PathArgs}}
+{{- $raw := printf "chi.URLParam(r, %q)" .Name}}
+{{- if eq $.Router "stdlib"}}{{$raw = printf "r.PathValue(%q)" .Name}}{{end}}
+{{- if .Parse}}
+	{{.GoName}}, err := {{.Parse}}({{$raw}})
+	if err != nil {
+		shared.WriteResponse(w, http.StatusBadRequest, &shared.ApiResponse[{{$returnType}}]{
+			Message: "invalid {{.Name}}: " + err.Error(),
+		})
+		return
+	}
+	_ = {{.GoName}}
+{{- else}}
+	 // {{.GoName}} := {{$raw}}
+{{- end}}
 {{- end}}
 {{- end}}
 {{- if .BodyArg}}
@@ -358,7 +439,6 @@ func (h *{{$.HandlerName}}Handler) {{.HandlerName}}(w http.ResponseWriter, r *ht
 {{- end}}
 {{- if .QueryArgs}}
 	// Query parameters:
-{{- $returnType := .GoReturnType}}
 {{- range .QueryArgs}}
 {{- if .IsComplex}}
 	 var {{.GoName}} {{.GoType}}
@@ -370,6 +450,28 @@ func (h *{{$.HandlerName}}Handler) {{.HandlerName}}(w http.ResponseWriter, r *ht
 	     })
 	     return
 	 }
+{{- else if and .Parse .Required}}
+	{{.GoName}}, err := {{.Parse}}(r.URL.Query().Get("{{.Name}}"))
+	if err != nil {
+		shared.WriteResponse(w, http.StatusBadRequest, &shared.ApiResponse[{{$returnType}}]{
+			Message: "invalid {{.Name}}: " + err.Error(),
+		})
+		return
+	}
+	_ = {{.GoName}}
+{{- else if .Parse}}
+	var {{.GoName}} {{.GoType}}
+	if raw := r.URL.Query().Get("{{.Name}}"); raw != "" {
+		v, err := {{.Parse}}(raw)
+		if err != nil {
+			shared.WriteResponse(w, http.StatusBadRequest, &shared.ApiResponse[{{$returnType}}]{
+				Message: "invalid {{.Name}}: " + err.Error(),
+			})
+			return
+		}
+		{{.GoName}} = &v
+	}
+	_ = {{.GoName}}
 {{- else}}
 	// {{.GoName}} := r.URL.Query().Get("{{.Name}}")
 {{- end}}
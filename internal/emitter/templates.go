@@ -0,0 +1,106 @@
+package emitter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// Template file names looked up in the configured templates directory.
+const (
+	RoutesTemplateFile       = "routes.tmpl"
+	TypesTemplateFile        = "types.tmpl"
+	DependenciesTemplateFile = "dependencies.tmpl"
+)
+
+// FuncMap returns the helper functions available to every template:
+//
+//	lower      strings.ToLower
+//	upper      strings.ToUpper
+//	title      strings.Title ("active" -> "Active")
+//	pascal     snake/kebab case to PascalCase ("business_locations" -> "BusinessLocations")
+//	exported   upper-cases the first letter ("createdAt" -> "CreatedAt")
+//	chiMethod  HTTP method to chi router method ("POST" -> "Post")
+//	join       strings.Join with the separator first, for pipelines: {{.PathParams | join ", "}}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+		"title":    strings.Title,
+		"pascal":   schema.PascalCase,
+		"exported": toExportedName,
+		"chiMethod": func(method string) string {
+			// Convert "POST" -> "Post", "GET" -> "Get", etc.
+			return strings.Title(strings.ToLower(method))
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+	}
+}
+
+// BuiltinTemplates returns the source of the built-in templates, keyed by
+// template file name.
+func BuiltinTemplates() map[string]string {
+	return map[string]string{
+		RoutesTemplateFile:       routesTemplate,
+		TypesTemplateFile:        typesTemplate,
+		DependenciesTemplateFile: depsTemplate,
+	}
+}
+
+// Templates are the parsed templates the emitters execute.
+type Templates struct {
+	Routes       *template.Template
+	Types        *template.Template
+	Dependencies *template.Template
+}
+
+// LoadTemplates parses the templates, replacing each built-in one with the
+// file of the same name in dir if it exists. An empty dir means built-ins
+// only.
+func LoadTemplates(dir string, readFile func(string) ([]byte, error)) (*Templates, error) {
+	load := func(name, builtin string) (*template.Template, error) {
+		src := builtin
+		path := "built-in " + name
+		if dir != "" {
+			p := filepath.Join(dir, name)
+			data, err := readFile(p)
+			switch {
+			case err == nil:
+				src = string(data)
+				path = p
+			case errors.Is(err, fs.ErrNotExist):
+				// Not overridden
+			default:
+				return nil, fmt.Errorf("reading template %s: %w", p, err)
+			}
+		}
+
+		tmpl, err := template.New(strings.TrimSuffix(name, ".tmpl")).Funcs(FuncMap()).Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", path, err)
+		}
+		return tmpl, nil
+	}
+
+	var (
+		t   Templates
+		err error
+	)
+	if t.Routes, err = load(RoutesTemplateFile, routesTemplate); err != nil {
+		return nil, err
+	}
+	if t.Types, err = load(TypesTemplateFile, typesTemplate); err != nil {
+		return nil, err
+	}
+	if t.Dependencies, err = load(DependenciesTemplateFile, depsTemplate); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
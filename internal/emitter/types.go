@@ -13,29 +13,33 @@ import (
 
 // TypesEmitter generates model types files.
 type TypesEmitter struct {
-	cfg *config.Config
+	cfg  *config.Config
+	tmpl *template.Template
 }
 
-// NewTypesEmitter creates a new types emitter.
+// NewTypesEmitter creates a new types emitter using the built-in template.
 func NewTypesEmitter(cfg *config.Config) *TypesEmitter {
-	return &TypesEmitter{cfg: cfg}
+	return &TypesEmitter{
+		cfg:  cfg,
+		tmpl: template.Must(template.New("types").Funcs(FuncMap()).Parse(typesTemplate)),
+	}
+}
+
+// SetTemplate replaces the template. It is executed with *typesTemplateData.
+func (e *TypesEmitter) SetTemplate(tmpl *template.Template) {
+	e.tmpl = tmpl
 }
 
 // Emit generates the types file content for a schema.
 func (e *TypesEmitter) Emit(s *schema.Schema) (string, error) {
-	data := e.buildTemplateData(s)
+	// Resolve types against the scalars s declares, on a copy so the
+	// emitter can be shared between schemas
+	e = &TypesEmitter{cfg: e.cfg.ForSchema(s), tmpl: e.tmpl}
 
-	funcMap := template.FuncMap{
-		"title": strings.Title,
-	}
-
-	tmpl, err := template.New("types").Funcs(funcMap).Parse(typesTemplate)
-	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
-	}
+	data := e.buildTemplateData(s)
 
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := e.tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
 
@@ -78,7 +82,7 @@ func (e *TypesEmitter) buildTemplateData(s *schema.Schema) *typesTemplateData {
 	// Build include aliases map (namespace -> import path)
 	includeImports := make(map[string]string)
 	for _, inc := range s.Includes {
-		if inc.Models != "" {
+		if inc.Models != "" && inc.Models != s.Models {
 			includeImports[inc.Namespace] = inc.Models
 		}
 	}
@@ -88,10 +92,18 @@ func (e *TypesEmitter) buildTemplateData(s *schema.Schema) *typesTemplateData {
 
 	collectFieldImports := func(fields []schema.Field) {
 		for _, f := range fields {
-			// Check for scalar imports (e.g., time.Time)
+			// Check for scalar imports (e.g., time.Time, decimal.Decimal)
 			if imp := e.importForType(f.Type); imp != "" {
 				importsMap[imp] = true
 			}
+			// Types bound with @goModel live in their own package,
+			// unless it is this one
+			if gm := s.GoModel(f.Type); gm != "" {
+				if _, imp := boundType(gm, s.Models, ""); imp != "" {
+					importsMap[imp] = true
+				}
+				continue
+			}
 			// Check for namespaced type imports
 			ns, _ := schema.ParseTypeRef(f.Type)
 			if ns != "" {
@@ -103,10 +115,14 @@ func (e *TypesEmitter) buildTemplateData(s *schema.Schema) *typesTemplateData {
 	}
 
 	for _, t := range s.Types {
-		collectFieldImports(t.Fields)
+		if t.GoModel == "" {
+			collectFieldImports(t.Fields)
+		}
 	}
 	for _, t := range s.Inputs {
-		collectFieldImports(t.Fields)
+		if t.GoModel == "" {
+			collectFieldImports(t.Fields)
+		}
 	}
 
 	var imports []string
@@ -115,15 +131,19 @@ func (e *TypesEmitter) buildTemplateData(s *schema.Schema) *typesTemplateData {
 	}
 	sort.Strings(imports)
 
-	// Build type definitions
+	// Build type definitions, skipping those bound to existing Go types
 	var types []typeDefData
 	for _, t := range s.Types {
-		types = append(types, e.buildTypeDef(t.Name, t.Fields))
+		if t.GoModel == "" {
+			types = append(types, e.buildTypeDef(s, t.Name, t.Fields))
+		}
 	}
 
 	var inputs []typeDefData
 	for _, t := range s.Inputs {
-		inputs = append(inputs, e.buildTypeDef(t.Name, t.Fields))
+		if t.GoModel == "" {
+			inputs = append(inputs, e.buildTypeDef(s, t.Name, t.Fields))
+		}
 	}
 
 	// Build enum definitions
@@ -144,11 +164,11 @@ func (e *TypesEmitter) buildTemplateData(s *schema.Schema) *typesTemplateData {
 	}
 }
 
-func (e *TypesEmitter) buildTypeDef(name string, fields []schema.Field) typeDefData {
+func (e *TypesEmitter) buildTypeDef(s *schema.Schema, name string, fields []schema.Field) typeDefData {
 	td := typeDefData{Name: name}
 
 	for _, f := range fields {
-		goType := e.resolveGoType(f.Type, f.Required, f.IsList)
+		goType := e.resolveGoType(s, f.Type, f.Required, f.IsList)
 
 		// Use field name as-is for JSON tag
 		jsonTag := f.Name
@@ -167,17 +187,30 @@ func (e *TypesEmitter) buildTypeDef(name string, fields []schema.Field) typeDefD
 	return td
 }
 
-// resolveGoType converts an SDL type to a Go type, handling namespaced types.
-func (e *TypesEmitter) resolveGoType(typeRef string, required bool, isList bool) string {
+// GoType returns the Go type a field or argument of type typeRef has in the
+// code generated for s.
+func (e *TypesEmitter) GoType(s *schema.Schema, typeRef string, required bool, isList bool) string {
+	return e.resolveGoType(s, typeRef, required, isList)
+}
+
+// resolveGoType converts an SDL type to a Go type, handling namespaced types
+// and types bound with @goModel.
+func (e *TypesEmitter) resolveGoType(s *schema.Schema, typeRef string, required bool, isList bool) string {
 	ns, typeName := schema.ParseTypeRef(typeRef)
 
 	var goType string
-	if ns != "" {
+	if gm := s.GoModel(typeRef); gm != "" {
+		// Bound type: github.com/acme/money.Amount -> money.Amount
+		goType, _ = boundType(gm, s.Models, "")
+	} else if ns != "" && !samePackage(s, ns) {
 		// Namespaced type: geo.Location -> geo.Location (package alias matches namespace)
 		goType = ns + "." + typeName
+	} else if ns != "" {
+		// Included type generated into this package
+		goType = typeName
 	} else if mapped, ok := e.cfg.Scalars[typeName]; ok {
 		// Scalar type
-		goType = mapped
+		goType = mapped.GoType()
 	} else {
 		// Local type (same package, no prefix needed)
 		goType = typeName
@@ -194,26 +227,10 @@ func (e *TypesEmitter) resolveGoType(typeRef string, required bool, isList bool)
 	return goType
 }
 
+// importForType returns the import path a scalar's Go type needs
+// (e.g., "time" for time.Time), or "" for builtins and non-scalars.
 func (e *TypesEmitter) importForType(typeName string) string {
-	goType, ok := e.cfg.Scalars[typeName]
-	if !ok {
-		return ""
-	}
-
-	switch goType {
-	case "time.Time":
-		return "time"
-	default:
-		// Check for package-qualified types like "decimal.Decimal"
-		if strings.Contains(goType, ".") {
-			parts := strings.Split(goType, ".")
-			if len(parts) == 2 {
-				// This is simplified - real impl would need full import path
-				return ""
-			}
-		}
-		return ""
-	}
+	return e.cfg.Scalars[typeName].Import
 }
 
 // toExportedName converts camelCase to PascalCase.
@@ -271,3 +288,33 @@ type {{.Name}} struct {
 {{- end}}
 }
 {{end}}`
+
+// samePackage reports whether the include with namespace ns generates its
+// types into the same package as s.
+func samePackage(s *schema.Schema, ns string) bool {
+	for _, inc := range s.Includes {
+		if inc.Namespace == ns {
+			return inc.Models != "" && inc.Models == s.Models
+		}
+	}
+	return false
+}
+
+// boundType returns the Go type of a type bound with @goModel(gm) and the
+// import it needs. A type bound into models, the models package of the
+// schema, is qualified with alias instead, or not at all from inside that
+// package (alias ""), and needs no import.
+func boundType(gm, models, alias string) (goType, imp string) {
+	bound := config.ScalarFromString(gm)
+	if bound.Import == "" || bound.Import != models {
+		return bound.GoType(), bound.Import
+	}
+	name := bound.Type
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	if alias == "" {
+		return name, ""
+	}
+	return alias + "." + name, ""
+}
@@ -0,0 +1,159 @@
+package gomod
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Module is a Go module on disk.
+type Module struct {
+	Path string // module path from go.mod
+	Dir  string // absolute directory containing go.mod
+}
+
+// Resolver maps import paths to directories using the go.mod, or the
+// go.work workspace, enclosing a directory.
+type Resolver struct {
+	// File is the go.mod or go.work the modules were read from.
+	File string
+
+	// Modules are sorted longest path first so nested modules win.
+	Modules []Module
+}
+
+// Find locates the workspace or module enclosing dir the way the go
+// command does, and returns a resolver for it: the go.work named by GOWORK,
+// or else the nearest go.work above dir (unless GOWORK=off), or else the
+// nearest go.mod. It returns nil if dir is not inside a module.
+func Find(dir string, readFile func(string) ([]byte, error)) (*Resolver, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gowork := os.Getenv("GOWORK")
+	if gowork != "" && gowork != "off" && gowork != "auto" {
+		workFile, err := filepath.Abs(gowork)
+		if err != nil {
+			return nil, err
+		}
+		return readWork(workFile, readFile)
+	}
+
+	var modFile, workFile string
+	for d := dir; ; d = filepath.Dir(d) {
+		if modFile == "" {
+			if ok, err := exists(readFile, filepath.Join(d, "go.mod")); err != nil {
+				return nil, err
+			} else if ok {
+				modFile = filepath.Join(d, "go.mod")
+			}
+		}
+		if workFile == "" && gowork != "off" {
+			if ok, err := exists(readFile, filepath.Join(d, "go.work")); err != nil {
+				return nil, err
+			} else if ok {
+				workFile = filepath.Join(d, "go.work")
+			}
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if workFile != "" {
+		return readWork(workFile, readFile)
+	}
+	if modFile != "" {
+		m, err := readModule(modFile, readFile)
+		if err != nil {
+			return nil, err
+		}
+		return &Resolver{File: modFile, Modules: []Module{m}}, nil
+	}
+	return nil, nil
+}
+
+// Dir returns the absolute directory of the package with importPath, or an
+// error if it isn't part of any module the resolver knows about.
+func (r *Resolver) Dir(importPath string) (string, error) {
+	for _, m := range r.Modules {
+		if importPath == m.Path {
+			return m.Dir, nil
+		}
+		if rest, ok := strings.CutPrefix(importPath, m.Path+"/"); ok {
+			return filepath.Join(m.Dir, filepath.FromSlash(rest)), nil
+		}
+	}
+
+	if len(r.Modules) == 1 {
+		return "", fmt.Errorf("package %s is outside module %s (%s)", importPath, r.Modules[0].Path, r.File)
+	}
+	var paths []string
+	for _, m := range r.Modules {
+		paths = append(paths, m.Path)
+	}
+	return "", fmt.Errorf("package %s is outside the workspace modules %s (%s)", importPath, strings.Join(paths, ", "), r.File)
+}
+
+func readWork(path string, readFile func(string) ([]byte, error)) (*Resolver, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wf, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Resolver{File: path}
+	for _, use := range wf.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+		m, err := readModule(filepath.Join(dir, "go.mod"), readFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		r.Modules = append(r.Modules, m)
+	}
+	sortModules(r.Modules)
+	return r, nil
+}
+
+func readModule(path string, readFile func(string) ([]byte, error)) (Module, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return Module{}, err
+	}
+	modPath := modfile.ModulePath(data)
+	if modPath == "" {
+		return Module{}, fmt.Errorf("%s: no module directive", path)
+	}
+	return Module{Path: modPath, Dir: filepath.Dir(path)}, nil
+}
+
+func sortModules(modules []Module) {
+	sort.SliceStable(modules, func(i, j int) bool {
+		return len(modules[i].Path) > len(modules[j].Path)
+	})
+}
+
+func exists(readFile func(string) ([]byte, error), path string) (bool, error) {
+	_, err := readFile(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,471 @@
+package importer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/borderlesshq/restgen/internal/routes"
+)
+
+// chiPackages are the import paths of chi's router package.
+var chiPackages = map[string]bool{
+	"github.com/go-chi/chi/v5": true,
+	"github.com/go-chi/chi":    true,
+}
+
+// chiRoute is a route registered on a chi router.
+type chiRoute struct {
+	method  string // lower case, as in SDL directives
+	path    string // full path, including Route and Mount prefixes
+	handler ast.Expr
+	pkg     *packages.Package // where handler appears
+	loc     string
+}
+
+// chiFinder discovers routes registered on chi routers.
+type chiFinder struct {
+	res     *Result
+	decls   map[*types.Func]*ast.FuncDecl
+	pkgs    map[*ast.FuncDecl]*packages.Package
+	walking map[*ast.FuncDecl]bool
+	seen    map[string]bool // method + path
+	routes  []chiRoute
+}
+
+// findChiRoutes finds the routes registered in pkgs, in the order they
+// are registered. Functions passed to Route or mounted with Mount are
+// followed with their prefix; every other function is a root.
+func findChiRoutes(pkgs []*packages.Package, res *Result) *chiFinder {
+	f := &chiFinder{
+		res:     res,
+		decls:   make(map[*types.Func]*ast.FuncDecl),
+		pkgs:    make(map[*ast.FuncDecl]*packages.Package),
+		walking: make(map[*ast.FuncDecl]bool),
+		seen:    make(map[string]bool),
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, d := range file.Decls {
+				fd, ok := d.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				if fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func); ok {
+					f.decls[fn] = fd
+					f.pkgs[fd] = pkg
+				}
+			}
+		}
+	}
+
+	// Functions reached through Route or Mount are walked from there
+	mounted := make(map[*ast.FuncDecl]bool)
+	for fd, pkg := range f.pkgs {
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				return true
+			}
+			if name, ok := f.routerCall(pkg, call); ok && (name == "Route" || name == "Mount") {
+				if target := f.funcDecl(pkg, call.Args[1]); target != nil {
+					mounted[target] = true
+				}
+			}
+			return true
+		})
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, d := range file.Decls {
+				if fd, ok := d.(*ast.FuncDecl); ok && fd.Body != nil && !mounted[fd] {
+					f.walkDecl(fd, "")
+				}
+			}
+		}
+	}
+	return f
+}
+
+// routerCall returns the name of the method call calls if it is called on
+// a chi router.
+func (f *chiFinder) routerCall(pkg *packages.Package, call *ast.CallExpr) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	t := pkg.TypesInfo.TypeOf(sel.X)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || !chiPackages[named.Obj().Pkg().Path()] {
+		return "", false
+	}
+	return sel.Sel.Name, true
+}
+
+func (f *chiFinder) walkDecl(fd *ast.FuncDecl, prefix string) {
+	if f.walking[fd] {
+		return
+	}
+	f.walking[fd] = true
+	defer delete(f.walking, fd)
+	f.walk(f.pkgs[fd], fd.Body, prefix)
+}
+
+// walk records the routes registered in body, prefixing their paths.
+func (f *chiFinder) walk(pkg *packages.Package, body ast.Node, prefix string) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, ok := f.routerCall(pkg, call)
+		if !ok {
+			return true
+		}
+		loc := position(pkg, call)
+
+		switch name {
+		case "Get", "Post", "Put", "Patch", "Delete":
+			if len(call.Args) == 2 {
+				f.add(pkg, strings.ToLower(name), prefix, call.Args[0], call.Args[1], loc)
+			}
+		case "Method", "MethodFunc":
+			if len(call.Args) != 3 {
+				return false
+			}
+			method, ok := stringConst(pkg, call.Args[0])
+			if !ok {
+				f.res.warnf("%s: method is not a constant, route skipped", loc)
+				return false
+			}
+			f.add(pkg, strings.ToLower(method), prefix, call.Args[1], call.Args[2], loc)
+		case "Head", "Options", "Connect", "Trace":
+			f.res.warnf("%s: %s routes are not supported, skipped", loc, strings.ToUpper(name))
+		case "Handle", "HandleFunc":
+			f.res.warnf("%s: %s serves every method, skipped", loc, name)
+		case "Group":
+			if len(call.Args) == 1 {
+				f.walkFunc(pkg, call.Args[0], prefix, loc)
+			}
+		case "Route", "Mount":
+			if len(call.Args) != 2 {
+				return false
+			}
+			pattern, ok := f.pattern(pkg, call.Args[0], loc)
+			if !ok {
+				return false
+			}
+			f.walkFunc(pkg, call.Args[1], routes.Join(prefix, pattern), loc)
+		default:
+			// Use, With and the like: look inside for registrations
+			return true
+		}
+		return false
+	})
+}
+
+// walkFunc walks the function literal or declared function (or the one a
+// call expression calls) that fn refers to.
+func (f *chiFinder) walkFunc(pkg *packages.Package, fn ast.Expr, prefix, loc string) {
+	if lit, ok := fn.(*ast.FuncLit); ok {
+		f.walk(pkg, lit.Body, prefix)
+		return
+	}
+	if fd := f.funcDecl(pkg, fn); fd != nil {
+		f.walkDecl(fd, prefix)
+		return
+	}
+	f.res.warnf("%s: can't follow %s, its routes are not imported", loc, types.ExprString(fn))
+}
+
+// funcDecl returns the declaration of the function expr names or calls,
+// if it is declared in the loaded packages.
+func (f *chiFinder) funcDecl(pkg *packages.Package, expr ast.Expr) *ast.FuncDecl {
+	if call, ok := expr.(*ast.CallExpr); ok {
+		expr = call.Fun
+	}
+	var id *ast.Ident
+	switch e := expr.(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	fn, ok := pkg.TypesInfo.Uses[id].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return f.decls[fn.Origin()]
+}
+
+// chiParamRe matches a chi path parameter, with an optional regexp.
+var chiParamRe = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// pattern returns the constant route pattern expr, with regexps stripped
+// from its parameters ({id:[0-9]+} -> {id}).
+func (f *chiFinder) pattern(pkg *packages.Package, expr ast.Expr, loc string) (string, bool) {
+	p, ok := stringConst(pkg, expr)
+	if !ok {
+		f.res.warnf("%s: pattern is not a constant, skipped", loc)
+		return "", false
+	}
+	if strings.Contains(p, "*") {
+		f.res.warnf("%s: wildcard pattern %q is not supported, skipped", loc, p)
+		return "", false
+	}
+	return chiParamRe.ReplaceAllString(p, "{$1}"), true
+}
+
+func (f *chiFinder) add(pkg *packages.Package, method, prefix string, pattern, handler ast.Expr, loc string) {
+	if !slices.Contains(methods, method) {
+		f.res.warnf("%s: %s routes are not supported, skipped", loc, strings.ToUpper(method))
+		return
+	}
+	p, ok := f.pattern(pkg, pattern, loc)
+	if !ok {
+		return
+	}
+	path := routes.Join(prefix, p)
+	if f.seen[method+" "+path] {
+		return
+	}
+	f.seen[method+" "+path] = true
+	f.routes = append(f.routes, chiRoute{method: method, path: path, handler: handler, pkg: pkg, loc: loc})
+}
+
+// stringConst returns the value of a constant string expression.
+func stringConst(pkg *packages.Package, expr ast.Expr) (string, bool) {
+	tv, ok := pkg.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// position is where n is, relative to the working directory when possible.
+func position(pkg *packages.Package, n ast.Node) string {
+	pos := pkg.Fset.Position(n.Pos())
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			pos.Filename = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+}
+
+// chiHandler is what a route's handler reveals about its call.
+type chiHandler struct {
+	name     string     // function or method name, "" for a literal
+	body     types.Type // decoded with a json.Decoder, or nil
+	response types.Type // encoded with a json.Encoder or json.Marshal, or nil
+	query    []string   // names read with r.URL.Query().Get
+}
+
+// handler inspects the function a route is served by.
+func (f *chiFinder) handler(r chiRoute) chiHandler {
+	expr := r.handler
+	// http.HandlerFunc(fn)
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if tv, ok := r.pkg.TypesInfo.Types[call.Fun]; ok && tv.IsType() {
+			expr = call.Args[0]
+		}
+	}
+
+	var h chiHandler
+	pkg := r.pkg
+	var body ast.Node
+	switch e := expr.(type) {
+	case *ast.FuncLit:
+		body = e.Body
+	case *ast.Ident:
+		h.name = e.Name
+	case *ast.SelectorExpr:
+		h.name = e.Sel.Name
+	}
+	if body == nil {
+		if fd := f.funcDecl(pkg, expr); fd != nil {
+			body, pkg = fd.Body, f.pkgs[fd]
+		}
+	}
+	if body == nil {
+		return h
+	}
+
+	seenQuery := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		arg := call.Args[0]
+
+		if fn, ok := pkg.TypesInfo.Uses[sel.Sel].(*types.Func); ok && fn.Pkg() != nil {
+			switch fn.Pkg().Path() + "." + fn.Name() {
+			case "encoding/json.Marshal":
+				if h.response == nil {
+					h.response = pkg.TypesInfo.TypeOf(arg)
+				}
+			}
+		}
+
+		recv := pkg.TypesInfo.TypeOf(sel.X)
+		switch {
+		case sel.Sel.Name == "Decode" && isNamed(recv, "encoding/json", "Decoder"):
+			if h.body == nil {
+				if p, ok := pkg.TypesInfo.TypeOf(arg).(*types.Pointer); ok {
+					h.body = p.Elem()
+				}
+			}
+		case sel.Sel.Name == "Encode" && isNamed(recv, "encoding/json", "Encoder"):
+			if h.response == nil {
+				h.response = pkg.TypesInfo.TypeOf(arg)
+			}
+		case sel.Sel.Name == "Get" && isNamed(recv, "net/url", "Values"):
+			if name, ok := stringConst(pkg, arg); ok && identRe.MatchString(name) && !seenQuery[name] {
+				seenQuery[name] = true
+				h.query = append(h.query, name)
+			}
+		}
+		return true
+	})
+	return h
+}
+
+// isNamed reports whether t, or what it points to, is the named type
+// pkgPath.name.
+func isNamed(t types.Type, pkgPath, name string) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+// emitRoutes groups the routes f found into files the way `restgen import
+// openapi` does, by their first path segment, and writes each group's
+// Calls. Files are named after the group, avoiding the names in used.
+func (imp *goImporter) emitRoutes(f *chiFinder, used map[string]bool, res *Result) {
+	var groups []string
+	byGroup := make(map[string][]chiRoute)
+	for _, r := range f.routes {
+		g := fileName(pathGroup(r.path))
+		if _, ok := byGroup[g]; !ok {
+			groups = append(groups, g)
+		}
+		byGroup[g] = append(byGroup[g], r)
+	}
+
+	for _, g := range groups {
+		group := byGroup[g]
+		var paths []string
+		for _, r := range group {
+			paths = append(paths, r.path)
+		}
+		base := commonBase(paths)
+
+		imp.reset(types.NewPackage("", g))
+		var calls []string
+		names := make(map[string]bool)
+		for _, r := range group {
+			calls = append(calls, imp.call(f, r, base, names))
+		}
+
+		var w sdlWriter
+		if base != "" {
+			w.directive("@base(%q)", base)
+		}
+		imp.references(&w)
+		w.block("type", "Calls", "", calls)
+
+		name := g
+		for used[name] {
+			name += "_api"
+		}
+		used[name] = true
+		res.Files = append(res.Files, File{Path: name + ".sdl", Content: w.bytes()})
+	}
+}
+
+// call renders a route as a Calls entry, named after its handler, with
+// the arguments and return type its handler reveals.
+func (imp *goImporter) call(f *chiFinder, r chiRoute, base string, names map[string]bool) string {
+	h := f.handler(r)
+
+	name := camel(h.name)
+	if name == "" {
+		name = r.method
+		for _, seg := range strings.Split(r.path, "/") {
+			if p, ok := strings.CutPrefix(seg, "{"); ok {
+				name += "By" + pascal(strings.TrimSuffix(p, "}"))
+			} else {
+				name += pascal(seg)
+			}
+		}
+	}
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	names[unique] = true
+
+	var args []string
+	argNames := make(map[string]bool)
+	for _, m := range chiParamRe.FindAllStringSubmatch(r.path, -1) {
+		typ := "String!"
+		if p := m[1]; p == "id" || strings.HasSuffix(p, "Id") || strings.HasSuffix(p, "ID") {
+			typ = "ID!"
+		}
+		argNames[m[1]] = true
+		args = append(args, m[1]+": "+typ)
+	}
+	for _, q := range h.query {
+		if !argNames[q] {
+			argNames[q] = true
+			args = append(args, q+": String")
+		}
+	}
+	if h.body != nil {
+		if ref, err := imp.fieldType(h.body); err != nil {
+			f.res.warnf("%s: request body %v, argument skipped", r.loc, err)
+		} else {
+			input := "input"
+			for i := 2; argNames[input]; i++ {
+				input = fmt.Sprintf("input%d", i)
+			}
+			args = append(args, input+": "+ref)
+		}
+	}
+
+	returns := "Boolean"
+	if h.response == nil {
+		f.res.warnf("%s: no JSON response found for %s %s, returns Boolean", r.loc, strings.ToUpper(r.method), r.path)
+	} else if ref, err := imp.fieldType(h.response); err != nil {
+		f.res.warnf("%s: response %v, returns Boolean", r.loc, err)
+	} else {
+		returns = ref
+	}
+
+	path := strings.TrimPrefix(r.path, base)
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s(%s): %s @%s(%q)", unique, strings.Join(args, ", "), returns, r.method, path)
+}
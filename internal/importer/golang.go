@@ -0,0 +1,514 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+// GoOptions configures FromGo.
+type GoOptions struct {
+	// Scalars are the configured scalar mappings. Go types that match one
+	// are written as that scalar (time.Time -> Time).
+	Scalars map[string]config.Scalar
+
+	// InputSuffixes are the struct name suffixes that become `input`
+	// blocks rather than `type` blocks (e.g., "Input", "Request").
+	InputSuffixes []string
+
+	// Bind adds @goModel to every block, so generation keeps using the
+	// existing structs instead of emitting new ones.
+	Bind bool
+}
+
+// builtinScalars are preferred when several scalars map to the same Go
+// type, so a string field becomes String rather than ID.
+var builtinScalars = []string{"String", "Int", "Float", "Boolean"}
+
+// FromGo loads the packages matching patterns and writes one SDL file per
+// package, named after the package. Exported structs become types or
+// inputs, and named string types with constants become enums. References
+// to structs in another imported package go through @include; structs
+// from packages that aren't imported are bound with @goModel. Routes
+// registered on chi routers become Calls, in files grouped by path.
+func FromGo(patterns []string, opts GoOptions) (*Result, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+	}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages match %s", strings.Join(patterns, " "))
+	}
+
+	imp := &goImporter{
+		opts:       opts,
+		scalars:    reverseScalars(opts.Scalars),
+		namespaces: make(map[string]string),
+		enums:      make(map[*types.TypeName]bool),
+	}
+
+	// Give every package a unique file name, which is also its include
+	// namespace.
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+	used := make(map[string]bool)
+	for _, pkg := range pkgs {
+		name := pkg.Name
+		for i := 2; used[name]; i++ {
+			name = pkg.Name + strconv.Itoa(i)
+		}
+		used[name] = true
+		imp.namespaces[pkg.PkgPath] = name
+	}
+
+	res := &Result{}
+	for _, pkg := range pkgs {
+		imp.findEnums(pkg.Types)
+	}
+	for _, pkg := range pkgs {
+		content := imp.file(pkg.Types, res)
+		if content == nil {
+			res.warnf("%s: no exported structs or enums", pkg.PkgPath)
+			continue
+		}
+		res.Files = append(res.Files, File{Path: imp.namespaces[pkg.PkgPath] + ".sdl", Content: content})
+	}
+	imp.emitRoutes(findChiRoutes(pkgs, res), used, res)
+	return res, nil
+}
+
+type goImporter struct {
+	opts       GoOptions
+	scalars    map[string]string // Go type key (see typeKey) -> scalar name
+	namespaces map[string]string // import path -> include namespace
+	enums      map[*types.TypeName]bool
+
+	// Per file state
+	pkg      *types.Package
+	includes map[string]bool            // namespaces referenced
+	bound    map[string]*types.TypeName // external structs, by SDL name
+}
+
+// reverseScalars maps Go types back to scalar names.
+func reverseScalars(scalars map[string]config.Scalar) map[string]string {
+	names := make([]string, 0, len(scalars))
+	for name := range scalars {
+		names = append(names, name)
+	}
+	rank := func(name string) int {
+		for i, b := range builtinScalars {
+			if b == name {
+				return i
+			}
+		}
+		return len(builtinScalars)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ri, rj := rank(names[i]), rank(names[j]); ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	out := make(map[string]string)
+	for _, name := range names {
+		sc := scalars[name]
+		key := sc.Type
+		if sc.Import != "" {
+			_, typeName, ok := strings.Cut(sc.Type, ".")
+			if !ok {
+				typeName = sc.Type
+			}
+			key = sc.Import + "." + typeName
+		}
+		if _, exists := out[key]; !exists {
+			out[key] = name
+		}
+	}
+	return out
+}
+
+// typeKey identifies a Go type the way reverseScalars does.
+func typeKey(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			return t.Obj().Name()
+		}
+		return t.Obj().Pkg().Path() + "." + t.Obj().Name()
+	case *types.Basic:
+		return t.Name()
+	}
+	return ""
+}
+
+// findEnums records the named string types of pkg that have constants whose
+// values are valid SDL enum values.
+func (imp *goImporter) findEnums(pkg *types.Package) {
+	for _, c := range imp.enumConsts(pkg) {
+		imp.enums[c[0].Type().(*types.Named).Obj()] = true
+	}
+}
+
+// enumConsts groups the constants of pkg by their named string type.
+func (imp *goImporter) enumConsts(pkg *types.Package) [][]*types.Const {
+	byType := make(map[*types.TypeName][]*types.Const)
+	var order []*types.TypeName
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !c.Exported() {
+			continue
+		}
+		named, ok := c.Type().(*types.Named)
+		if !ok || named.Obj().Pkg() != pkg || !named.Obj().Exported() {
+			continue
+		}
+		if b, ok := named.Underlying().(*types.Basic); !ok || b.Kind() != types.String {
+			continue
+		}
+		if _, seen := byType[named.Obj()]; !seen {
+			order = append(order, named.Obj())
+		}
+		byType[named.Obj()] = append(byType[named.Obj()], c)
+	}
+
+	var out [][]*types.Const
+	for _, tn := range order {
+		consts := byType[tn]
+		valid := true
+		for _, c := range consts {
+			if !identRe.MatchString(constant.StringVal(c.Val())) {
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+		sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+		out = append(out, consts)
+	}
+	return out
+}
+
+// reset starts a file whose types are declared in pkg.
+func (imp *goImporter) reset(pkg *types.Package) {
+	imp.pkg = pkg
+	imp.includes = make(map[string]bool)
+	imp.bound = make(map[string]*types.TypeName)
+}
+
+// references writes the @include directives and the @goModel blocks for
+// the types the current file refers to.
+func (imp *goImporter) references(w *sdlWriter) {
+	var includes []string
+	for ns := range imp.includes {
+		includes = append(includes, ns)
+	}
+	sort.Strings(includes)
+	for _, ns := range includes {
+		w.directive("@include(%q)", ns+".sdl")
+	}
+
+	var bound []string
+	for name := range imp.bound {
+		bound = append(bound, name)
+	}
+	sort.Strings(bound)
+	for _, name := range bound {
+		tn := imp.bound[name]
+		w.block("type", name, fmt.Sprintf("@goModel(%q)", tn.Pkg().Path()+"."+tn.Name()), nil)
+	}
+}
+
+// file renders the SDL for pkg, or nil if it has nothing to import.
+func (imp *goImporter) file(pkg *types.Package, res *Result) []byte {
+	imp.reset(pkg)
+
+	type decl struct {
+		pos  int
+		kind string
+		name string
+		dirs string
+		body []string
+	}
+	var decls []decl
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		if named.TypeParams().Len() > 0 {
+			res.warnf("%s.%s: generic structs are not supported", pkg.Path(), name)
+			continue
+		}
+
+		kind := "type"
+		for _, suffix := range imp.opts.InputSuffixes {
+			if suffix != "" && strings.HasSuffix(name, suffix) {
+				kind = "input"
+			}
+		}
+		var dirs string
+		if imp.opts.Bind {
+			dirs = fmt.Sprintf("@goModel(%q)", pkg.Path()+"."+name)
+		}
+
+		body := imp.fields(st, pkg.Path()+"."+name, res)
+		if len(body) == 0 && dirs == "" {
+			res.warnf("%s.%s: no fields with a JSON representation", pkg.Path(), name)
+			continue
+		}
+		decls = append(decls, decl{pos: int(tn.Pos()), kind: kind, name: name, dirs: dirs, body: body})
+	}
+
+	for _, consts := range imp.enumConsts(pkg) {
+		tn := consts[0].Type().(*types.Named).Obj()
+		var values []string
+		for _, c := range consts {
+			values = append(values, constant.StringVal(c.Val()))
+		}
+		decls = append(decls, decl{pos: int(tn.Pos()), kind: "enum", name: tn.Name(), body: values})
+	}
+
+	if len(decls) == 0 {
+		return nil
+	}
+	sort.SliceStable(decls, func(i, j int) bool { return decls[i].pos < decls[j].pos })
+
+	var w sdlWriter
+	imp.references(&w)
+	for _, d := range decls {
+		w.block(d.kind, d.name, d.dirs, d.body)
+	}
+	return w.bytes()
+}
+
+// fields returns the SDL field lines for st, flattening embedded structs
+// the way encoding/json does: a field hides fields of the same JSON name
+// nested deeper, and names that clash at the same depth are dropped.
+func (imp *goImporter) fields(st *types.Struct, owner string, res *Result) []string {
+	var all []jsonField
+	collectFields(st, 0, &all)
+
+	// Group by name, keeping the order names first appear in
+	var names []string
+	byName := make(map[string][]jsonField)
+	for _, f := range all {
+		if _, ok := byName[f.name]; !ok {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	var lines []string
+	for _, name := range names {
+		f, ok := dominantField(byName[name])
+		if !ok {
+			res.warnf("%s: JSON name %q is used by several embedded fields at the same depth, skipped", owner, name)
+			continue
+		}
+		if !identRe.MatchString(name) {
+			res.warnf("%s.%s: JSON name %q is not a valid SDL field name, skipped", owner, f.v.Name(), name)
+			continue
+		}
+
+		ref, err := imp.fieldType(f.v.Type())
+		if err != nil {
+			res.warnf("%s.%s: %v, skipped", owner, f.v.Name(), err)
+			continue
+		}
+		lines = append(lines, name+": "+ref)
+	}
+	return lines
+}
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name   string
+	v      *types.Var
+	depth  int  // levels of embedding
+	tagged bool // the name comes from a json tag
+}
+
+// collectFields appends the JSON fields of st, including those promoted
+// from embedded structs, to out.
+func collectFields(st *types.Struct, depth int, out *[]jsonField) {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+
+		if f.Embedded() && name == "" {
+			t := f.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if inner, ok := t.Underlying().(*types.Struct); ok {
+				collectFields(inner, depth+1, out)
+				continue
+			}
+		}
+
+		if !f.Exported() {
+			continue
+		}
+		tagged := name != ""
+		if name == "" {
+			name = f.Name()
+		}
+		*out = append(*out, jsonField{name: name, v: f, depth: depth, tagged: tagged})
+	}
+}
+
+// dominantField picks the field encoding/json uses among fields with the
+// same name: the shallowest, preferring a tagged one when several are
+// equally shallow. It reports false if that leaves more than one.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	var shallowest []jsonField
+	for _, f := range fields {
+		switch {
+		case len(shallowest) == 0 || f.depth < shallowest[0].depth:
+			shallowest = []jsonField{f}
+		case f.depth == shallowest[0].depth:
+			shallowest = append(shallowest, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+
+	var tagged []jsonField
+	for _, f := range shallowest {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// fieldType maps a field's Go type to an SDL type reference: pointers are
+// nullable, everything else is required.
+func (imp *goImporter) fieldType(t types.Type) (string, error) {
+	required := true
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+		required = false
+	}
+
+	if s, ok := t.(*types.Slice); ok && !isBytes(s) {
+		elem := s.Elem()
+		itemRequired := true
+		if p, ok := elem.(*types.Pointer); ok {
+			elem = p.Elem()
+			itemRequired = false
+		}
+		name, err := imp.typeName(elem)
+		if err != nil {
+			return "", err
+		}
+		return typeString(name, required, true, itemRequired), nil
+	}
+
+	name, err := imp.typeName(t)
+	if err != nil {
+		return "", err
+	}
+	return typeString(name, required, false, false), nil
+}
+
+// typeName maps a non-pointer, non-slice Go type to the SDL name of a
+// scalar, enum or type.
+func (imp *goImporter) typeName(t types.Type) (string, error) {
+	if name, ok := imp.scalars[typeKey(t)]; ok {
+		return name, nil
+	}
+
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		tn := named.Obj()
+		ns, imported := imp.namespaces[tn.Pkg().Path()]
+		qualify := func() string {
+			if tn.Pkg() == imp.pkg {
+				return tn.Name()
+			}
+			imp.includes[ns] = true
+			return ns + "." + tn.Name()
+		}
+
+		if imp.enums[tn] {
+			return qualify(), nil
+		}
+		if _, ok := named.Underlying().(*types.Struct); ok {
+			if imported && tn.Exported() {
+				return qualify(), nil
+			}
+			if prev, ok := imp.bound[tn.Name()]; ok && prev != tn {
+				return "", fmt.Errorf("%s and %s would both be bound as %s", prev.Pkg().Path()+"."+prev.Name(), tn.Pkg().Path()+"."+tn.Name(), tn.Name())
+			}
+			if imp.pkg.Scope().Lookup(tn.Name()) != nil {
+				return "", fmt.Errorf("%s clashes with a type of the same name in this package", tn.Pkg().Path()+"."+tn.Name())
+			}
+			imp.bound[tn.Name()] = tn
+			return tn.Name(), nil
+		}
+	}
+
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		switch {
+		case b.Info()&types.IsString != 0:
+			return "String", nil
+		case b.Info()&types.IsBoolean != 0:
+			return "Boolean", nil
+		case b.Info()&types.IsInteger != 0:
+			return "Int", nil
+		case b.Info()&types.IsFloat != 0:
+			return "Float", nil
+		}
+	}
+	if s, ok := t.(*types.Slice); ok && isBytes(s) {
+		return "String", nil
+	}
+
+	return "", fmt.Errorf("%s has no SDL equivalent", types.TypeString(t, types.RelativeTo(imp.pkg)))
+}
+
+func isBytes(s *types.Slice) bool {
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
@@ -0,0 +1,91 @@
+// Package importer produces SDL from existing code and API descriptions, so
+// a service can adopt restgen without hand-writing its schemas.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// File is an SDL file produced by an importer. Path is relative to the
+// output directory.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Result is the output of an import.
+type Result struct {
+	Files []File
+
+	// Warnings describe what could not be represented in SDL and was
+	// skipped or approximated.
+	Warnings []string
+}
+
+func (r *Result) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+var identRe = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// sdlWriter builds an SDL file in the layout `restgen init` and the
+// examples use: header directives, then blocks separated by blank lines.
+type sdlWriter struct {
+	header []string
+	blocks []string
+}
+
+func (w *sdlWriter) directive(format string, args ...any) {
+	w.header = append(w.header, fmt.Sprintf(format, args...))
+}
+
+// block adds `kind name directives {` with one line per entry. A block
+// without lines and with directives is written without a body.
+func (w *sdlWriter) block(kind, name, directives string, lines []string) {
+	var b strings.Builder
+	b.WriteString(kind + " " + name)
+	if directives != "" {
+		b.WriteString(" " + directives)
+	}
+	if len(lines) == 0 && directives != "" {
+		w.blocks = append(w.blocks, b.String()+"\n")
+		return
+	}
+	b.WriteString(" {\n")
+	for _, l := range lines {
+		b.WriteString("    " + l + "\n")
+	}
+	b.WriteString("}\n")
+	w.blocks = append(w.blocks, b.String())
+}
+
+func (w *sdlWriter) bytes() []byte {
+	var b strings.Builder
+	for _, h := range w.header {
+		b.WriteString(h + "\n")
+	}
+	for i, blk := range w.blocks {
+		if i > 0 || len(w.header) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(blk)
+	}
+	return []byte(b.String())
+}
+
+// typeString renders an SDL type reference.
+func typeString(name string, required, list, itemRequired bool) string {
+	if list {
+		name = "[" + name
+		if itemRequired {
+			name += "!"
+		}
+		name += "]"
+	}
+	if required {
+		name += "!"
+	}
+	return name
+}
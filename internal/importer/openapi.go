@@ -0,0 +1,969 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+// SharedFile is the file component schemas used by more than one group are
+// written to. It is an @library included by the group files.
+const SharedFile = "components.sdl"
+
+const sharedNamespace = "components"
+
+// OpenAPIOptions configures FromOpenAPI.
+type OpenAPIOptions struct {
+	// Scalars are the configured scalar mappings. A string schema whose
+	// format matches a scalar's openapiFormat is written as that scalar.
+	Scalars map[string]config.Scalar
+}
+
+// FromOpenAPI converts an OpenAPI 3.x document, in YAML or JSON, into SDL.
+// Operations are grouped by their first tag, or by the first path segment
+// when untagged, and each group becomes a file with its own Calls.
+// Component schemas used by a single group are written to its file; the
+// rest go to SharedFile. Anything SDL can't express is left out and
+// reported in Result.Warnings.
+func FromOpenAPI(name string, data []byte, opts OpenAPIOptions) (*Result, error) {
+	var doc oaDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		if doc.Swagger != "" {
+			return nil, fmt.Errorf("%s: Swagger %s is not supported, convert it to OpenAPI 3 first", name, doc.Swagger)
+		}
+		return nil, fmt.Errorf("%s: not an OpenAPI 3.x document", name)
+	}
+
+	imp := &oaImporter{
+		doc:     &doc,
+		res:     &Result{},
+		comps:   make(map[string]*oaComponent),
+		formats: make(map[string]string),
+	}
+
+	// Prefer builtins for formats restgen's defaults know about, then any
+	// configured openapiFormat.
+	if _, ok := opts.Scalars["Time"]; ok {
+		imp.formats["date-time"] = "Time"
+	}
+	var names []string
+	for n := range opts.Scalars {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if f := opts.Scalars[n].OpenAPIFormat; f != "" {
+			imp.formats[f] = n
+		}
+	}
+
+	for _, key := range doc.Components.Schemas.Keys {
+		imp.addComponent(key, doc.Components.Schemas.Values[key], "components.schemas."+key)
+	}
+	// Operations hoist inline bodies, responses and parameters into
+	// components, whose own inline properties are hoisted after them
+	imp.collectOperations()
+	imp.hoistComponents()
+	imp.assignComponents()
+	imp.checkEnums()
+
+	imp.emit()
+	return imp.res, nil
+}
+
+// OpenAPI document model: only what the importer reads.
+
+type oaDocument struct {
+	OpenAPI    string                 `yaml:"openapi"`
+	Swagger    string                 `yaml:"swagger"`
+	Paths      oaOrdered[*oaPathItem] `yaml:"paths"`
+	Components oaComponents           `yaml:"components"`
+}
+
+type oaComponents struct {
+	Schemas       oaOrdered[*oaSchema]      `yaml:"schemas"`
+	Parameters    map[string]*oaParameter   `yaml:"parameters"`
+	RequestBodies map[string]*oaRequestBody `yaml:"requestBodies"`
+	Responses     map[string]*oaResponse    `yaml:"responses"`
+}
+
+type oaPathItem struct {
+	Parameters []*oaParameter `yaml:"parameters"`
+	Get        *oaOperation   `yaml:"get"`
+	Put        *oaOperation   `yaml:"put"`
+	Post       *oaOperation   `yaml:"post"`
+	Delete     *oaOperation   `yaml:"delete"`
+	Options    *oaOperation   `yaml:"options"`
+	Head       *oaOperation   `yaml:"head"`
+	Patch      *oaOperation   `yaml:"patch"`
+	Trace      *oaOperation   `yaml:"trace"`
+}
+
+type oaOperation struct {
+	OperationID string                 `yaml:"operationId"`
+	Tags        []string               `yaml:"tags"`
+	Parameters  []*oaParameter         `yaml:"parameters"`
+	RequestBody *oaRequestBody         `yaml:"requestBody"`
+	Responses   oaOrdered[*oaResponse] `yaml:"responses"`
+}
+
+type oaParameter struct {
+	Ref      string    `yaml:"$ref"`
+	Name     string    `yaml:"name"`
+	In       string    `yaml:"in"`
+	Required bool      `yaml:"required"`
+	Schema   *oaSchema `yaml:"schema"`
+}
+
+type oaRequestBody struct {
+	Ref      string              `yaml:"$ref"`
+	Required bool                `yaml:"required"`
+	Content  map[string]*oaMedia `yaml:"content"`
+}
+
+type oaResponse struct {
+	Ref     string              `yaml:"$ref"`
+	Content map[string]*oaMedia `yaml:"content"`
+}
+
+type oaMedia struct {
+	Schema *oaSchema `yaml:"schema"`
+}
+
+type oaSchema struct {
+	Ref                  string               `yaml:"$ref"`
+	Type                 any                  `yaml:"type"` // a string, or a list in 3.1
+	Format               string               `yaml:"format"`
+	Nullable             bool                 `yaml:"nullable"`
+	Enum                 []any                `yaml:"enum"`
+	Properties           oaOrdered[*oaSchema] `yaml:"properties"`
+	Required             []string             `yaml:"required"`
+	Items                *oaSchema            `yaml:"items"`
+	AllOf                []*oaSchema          `yaml:"allOf"`
+	OneOf                []*oaSchema          `yaml:"oneOf"`
+	AnyOf                []*oaSchema          `yaml:"anyOf"`
+	AdditionalProperties any                  `yaml:"additionalProperties"`
+}
+
+// typ returns the schema's type and whether a 3.1 type list allows null.
+func (s *oaSchema) typ() (string, bool) {
+	switch t := s.Type.(type) {
+	case string:
+		return t, false
+	case []any:
+		var typ string
+		null := false
+		for _, v := range t {
+			if v == "null" {
+				null = true
+			} else if str, ok := v.(string); ok && typ == "" {
+				typ = str
+			}
+		}
+		return typ, null
+	}
+	if s.Properties.Len() > 0 || len(s.AllOf) > 0 {
+		return "object", false
+	}
+	return "", false
+}
+
+func (s *oaSchema) nullable() bool {
+	_, null := s.typ()
+	return s.Nullable || null
+}
+
+// isObject reports whether s describes an object with known properties.
+func (s *oaSchema) isObject() bool {
+	t, _ := s.typ()
+	return s.Ref == "" && len(s.Enum) == 0 && (t == "object" || t == "") && (s.Properties.Len() > 0 || len(s.AllOf) > 0)
+}
+
+// oaOrdered is a YAML mapping that remembers its key order, so SDL comes
+// out in the order the document was written in.
+type oaOrdered[T any] struct {
+	Keys   []string
+	Values map[string]T
+}
+
+func (o *oaOrdered[T]) Len() int { return len(o.Keys) }
+
+func (o *oaOrdered[T]) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", n.Line)
+	}
+	o.Values = make(map[string]T)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		var v T
+		if err := n.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		if _, dup := o.Values[key]; !dup {
+			o.Keys = append(o.Keys, key)
+		}
+		o.Values[key] = v
+	}
+	return nil
+}
+
+// oaComponent is a named schema: a component, or an inline object or enum
+// hoisted out of a property, body or response.
+type oaComponent struct {
+	key    string // key in components.schemas, or a generated name
+	name   string // SDL name
+	schema *oaSchema
+	loc    string // where it was defined, for warnings
+
+	input  bool            // reachable from a request body or query parameter
+	output bool            // reachable from a response or unused
+	groups map[string]bool // groups that reach it
+	file   string          // group it is written to, or "" for SharedFile
+
+	values []string // enum values
+
+	// alias is set for components that aren't objects or enums; references
+	// to them use it directly.
+	alias    *oaSchema
+	resolved bool // alias resolution in progress, to stop cycles
+}
+
+type oaOp struct {
+	method   string
+	path     string
+	group    string
+	name     string
+	loc      string
+	params   []*oaParameter // path params, then query params
+	body     *oaSchema
+	bodyReq  bool
+	response *oaSchema
+}
+
+type oaImporter struct {
+	doc     *oaDocument
+	res     *Result
+	comps   map[string]*oaComponent // by key
+	order   []*oaComponent
+	names   map[string]bool // SDL names in use
+	formats map[string]string
+	ops     []*oaOp
+	groups  []string // in order of first appearance
+
+	// Per file state while emitting
+	file     string
+	includes bool
+}
+
+var nonWordRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// pascal turns "pet-status", "pet_status" or "pet status" into "PetStatus".
+func pascal(s string) string {
+	var b strings.Builder
+	for _, part := range nonWordRe.Split(s, -1) {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	out := b.String()
+	if out != "" && out[0] >= '0' && out[0] <= '9' {
+		out = "T" + out
+	}
+	return out
+}
+
+// camel is pascal with a lower-case first letter.
+func camel(s string) string {
+	p := pascal(s)
+	if p == "" {
+		return p
+	}
+	return strings.ToLower(p[:1]) + p[1:]
+}
+
+func (imp *oaImporter) addComponent(key string, s *oaSchema, loc string) *oaComponent {
+	if imp.names == nil {
+		imp.names = make(map[string]bool)
+	}
+	name := pascal(key)
+	if name == "" {
+		name = "Type"
+	}
+	base := name
+	for i := 2; imp.names[name] || imp.names[name+"Input"]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	imp.names[name] = true
+	if _, exists := imp.comps[key]; exists {
+		key += "#" + name
+	}
+
+	c := &oaComponent{key: key, name: name, schema: s, loc: loc, groups: make(map[string]bool)}
+	imp.comps[key] = c
+	imp.order = append(imp.order, c)
+	return c
+}
+
+// hoist replaces an inline object or enum with a reference to a new
+// component called name, so it can be declared as an SDL block.
+func (imp *oaImporter) hoist(s *oaSchema, name, loc string) *oaSchema {
+	if s == nil {
+		return nil
+	}
+	if t, _ := s.typ(); t == "array" && s.Items != nil {
+		s.Items = imp.hoist(s.Items, name+"Item", loc+".items")
+		return s
+	}
+	if !s.isObject() && !(s.Ref == "" && len(s.Enum) > 0) {
+		return s
+	}
+	c := imp.addComponent(name, s, loc)
+	return &oaSchema{Ref: "#/components/schemas/" + c.key, Nullable: s.Nullable}
+}
+
+// hoistComponents hoists inline objects and enums out of every component's
+// properties, including components hoisted along the way.
+func (imp *oaImporter) hoistComponents() {
+	for i := 0; i < len(imp.order); i++ {
+		c := imp.order[i]
+		imp.hoistProperties(c.schema, c.name, c.loc)
+	}
+}
+
+func (imp *oaImporter) hoistProperties(s *oaSchema, owner, loc string) {
+	for _, key := range s.Properties.Keys {
+		s.Properties.Values[key] = imp.hoist(s.Properties.Values[key], owner+pascal(key), loc+".properties."+key)
+	}
+	for i, part := range s.AllOf {
+		if part.Ref == "" {
+			imp.hoistProperties(part, owner, fmt.Sprintf("%s.allOf[%d]", loc, i))
+		}
+	}
+}
+
+// component resolves a local schema $ref.
+func (imp *oaImporter) component(ref string) (*oaComponent, error) {
+	key, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return nil, fmt.Errorf("reference %s is not a local component schema", ref)
+	}
+	c, ok := imp.comps[key]
+	if !ok {
+		return nil, fmt.Errorf("reference %s does not exist", ref)
+	}
+	return c, nil
+}
+
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+func (imp *oaImporter) collectOperations() {
+	seen := make(map[string]bool)
+	names := make(map[string]map[string]bool) // group -> call names
+
+	for _, path := range imp.doc.Paths.Keys {
+		item := imp.doc.Paths.Values[path]
+		if item == nil {
+			continue
+		}
+		for method, op := range map[string]*oaOperation{"head": item.Head, "options": item.Options, "trace": item.Trace} {
+			if op != nil {
+				imp.res.warnf("paths.%s.%s: %s operations are not supported", path, method, strings.ToUpper(method))
+			}
+		}
+
+		ops := map[string]*oaOperation{"get": item.Get, "post": item.Post, "put": item.Put, "patch": item.Patch, "delete": item.Delete}
+		for _, method := range methods {
+			op := ops[method]
+			if op == nil {
+				continue
+			}
+			loc := "paths." + path + "." + method
+			o, ok := imp.operation(method, path, item, op, loc)
+			if !ok {
+				continue
+			}
+
+			if !seen[o.group] {
+				seen[o.group] = true
+				imp.groups = append(imp.groups, o.group)
+				names[o.group] = make(map[string]bool)
+			}
+			name := o.name
+			for i := 2; names[o.group][name]; i++ {
+				name = fmt.Sprintf("%s%d", o.name, i)
+			}
+			names[o.group][name] = true
+			o.name = name
+
+			imp.ops = append(imp.ops, o)
+		}
+	}
+}
+
+// operation converts a single operation, reporting and returning false if
+// it can't be represented.
+func (imp *oaImporter) operation(method, path string, item *oaPathItem, op *oaOperation, loc string) (*oaOp, bool) {
+	o := &oaOp{method: method, path: path, loc: loc}
+
+	if len(op.Tags) > 0 {
+		o.group = fileName(op.Tags[0])
+	} else {
+		o.group = fileName(pathGroup(path))
+	}
+
+	o.name = camel(op.OperationID)
+	if o.name == "" {
+		o.name = method
+		for _, seg := range strings.Split(path, "/") {
+			if p, ok := strings.CutPrefix(seg, "{"); ok {
+				o.name += "By" + pascal(strings.TrimSuffix(p, "}"))
+			} else {
+				o.name += pascal(seg)
+			}
+		}
+	}
+
+	// Operation parameters override path item parameters with the same
+	// name and location.
+	var params []*oaParameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*oaParameter{}, item.Parameters...), op.Parameters...) {
+		p, err := imp.parameter(p)
+		if err != nil {
+			imp.res.warnf("%s: %v, skipped", loc, err)
+			return nil, false
+		}
+		if i, ok := index[p.In+" "+p.Name]; ok {
+			params[i] = p
+			continue
+		}
+		index[p.In+" "+p.Name] = len(params)
+		params = append(params, p)
+	}
+
+	var query []*oaParameter
+	for _, p := range params {
+		switch p.In {
+		case "path", "query":
+			if !identRe.MatchString(p.Name) {
+				imp.res.warnf("%s: parameter name %q is not a valid SDL argument name, operation skipped", loc, p.Name)
+				return nil, false
+			}
+			if p.Schema == nil {
+				p.Schema = &oaSchema{Type: "string"}
+			}
+			p.Schema = imp.hoist(p.Schema, pascal(o.name)+pascal(p.Name), loc+".parameters."+p.Name)
+			if p.In == "path" {
+				p.Required = true
+				o.params = append(o.params, p)
+			} else {
+				query = append(query, p)
+			}
+		default:
+			imp.res.warnf("%s: %s parameter %s is not supported, skipped", loc, p.In, p.Name)
+		}
+	}
+	o.params = append(o.params, query...)
+
+	if op.RequestBody != nil {
+		body, err := imp.requestBody(op.RequestBody)
+		if err != nil {
+			imp.res.warnf("%s.requestBody: %v, skipped", loc, err)
+		} else if s, ok := jsonSchema(body.Content); ok {
+			o.body = imp.hoist(s, pascal(o.name)+"Input", loc+".requestBody")
+			o.bodyReq = body.Required
+		} else {
+			imp.res.warnf("%s.requestBody: only JSON bodies are supported, skipped", loc)
+		}
+	}
+
+	for _, code := range op.Responses.Keys {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		resp, err := imp.response(op.Responses.Values[code])
+		if err != nil {
+			imp.res.warnf("%s.responses.%s: %v", loc, code, err)
+			break
+		}
+		if s, ok := jsonSchema(resp.Content); ok {
+			o.response = imp.hoist(s, pascal(o.name)+"Response", loc+".responses."+code)
+		}
+		break
+	}
+	if o.response == nil {
+		imp.res.warnf("%s: no JSON success response, returns Boolean", loc)
+	}
+
+	return o, true
+}
+
+// pathGroup is the first path segment that isn't a parameter or a version
+// such as v1.
+func pathGroup(path string) string {
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" || strings.HasPrefix(seg, "{") || versionRe.MatchString(seg) {
+			continue
+		}
+		return seg
+	}
+	return "root"
+}
+
+var versionRe = regexp.MustCompile(`^v[0-9]+$`)
+
+// fileName turns a tag or path segment into a file base name.
+func fileName(s string) string {
+	name := strings.Trim(nonWordRe.ReplaceAllString(strings.ToLower(s), "_"), "_")
+	if name == "" || name == sharedNamespace {
+		name += "_api"
+	}
+	return strings.TrimPrefix(name, "_")
+}
+
+func jsonSchema(content map[string]*oaMedia) (*oaSchema, bool) {
+	var types []string
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		mt, _, _ := strings.Cut(t, ";")
+		if (mt == "application/json" || strings.HasSuffix(mt, "+json")) && content[t] != nil && content[t].Schema != nil {
+			return content[t].Schema, true
+		}
+	}
+	return nil, false
+}
+
+func (imp *oaImporter) parameter(p *oaParameter) (*oaParameter, error) {
+	if p.Ref == "" {
+		cp := *p
+		return &cp, nil
+	}
+	key, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+	if !ok || imp.doc.Components.Parameters[key] == nil {
+		return nil, fmt.Errorf("parameter reference %s can't be resolved", p.Ref)
+	}
+	return imp.parameter(imp.doc.Components.Parameters[key])
+}
+
+func (imp *oaImporter) requestBody(b *oaRequestBody) (*oaRequestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	key, ok := strings.CutPrefix(b.Ref, "#/components/requestBodies/")
+	if !ok || imp.doc.Components.RequestBodies[key] == nil {
+		return nil, fmt.Errorf("request body reference %s can't be resolved", b.Ref)
+	}
+	return imp.requestBody(imp.doc.Components.RequestBodies[key])
+}
+
+func (imp *oaImporter) response(r *oaResponse) (*oaResponse, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	key, ok := strings.CutPrefix(r.Ref, "#/components/responses/")
+	if !ok || imp.doc.Components.Responses[key] == nil {
+		return nil, fmt.Errorf("response reference %s can't be resolved", r.Ref)
+	}
+	return imp.response(imp.doc.Components.Responses[key])
+}
+
+// assignComponents marks how each component is used and decides which file
+// declares it.
+func (imp *oaImporter) assignComponents() {
+	for _, o := range imp.ops {
+		for _, p := range o.params {
+			imp.use(p.Schema, true, o.group)
+		}
+		imp.use(o.body, true, o.group)
+		imp.use(o.response, false, o.group)
+	}
+
+	for _, c := range imp.order {
+		if !c.input && !c.output {
+			c.output = true
+		}
+		if len(c.groups) == 1 {
+			for g := range c.groups {
+				c.file = g
+			}
+		}
+	}
+
+	// A shared component can only refer to other shared components
+	for changed := true; changed; {
+		changed = false
+		for _, c := range imp.order {
+			if c.file != "" {
+				continue
+			}
+			for _, ref := range imp.refs(c.schema) {
+				if ref.file != "" {
+					ref.file = ""
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// use marks every component reachable from s as used by group.
+func (imp *oaImporter) use(s *oaSchema, input bool, group string) {
+	for _, c := range imp.refs(s) {
+		if c.groups[group] && (input && c.input || !input && c.output) {
+			continue
+		}
+		c.groups[group] = true
+		if input {
+			c.input = true
+		} else {
+			c.output = true
+		}
+		imp.use(c.schema, input, group)
+	}
+}
+
+// refs returns the components s refers to directly.
+func (imp *oaImporter) refs(s *oaSchema) []*oaComponent {
+	if s == nil {
+		return nil
+	}
+	var out []*oaComponent
+	if s.Ref != "" {
+		if c, err := imp.component(s.Ref); err == nil {
+			out = append(out, c)
+		}
+		return out
+	}
+	out = append(out, imp.refs(s.Items)...)
+	for _, key := range s.Properties.Keys {
+		out = append(out, imp.refs(s.Properties.Values[key])...)
+	}
+	for _, part := range s.AllOf {
+		out = append(out, imp.refs(part)...)
+	}
+	return out
+}
+
+func (imp *oaImporter) emit() {
+	var shared sdlWriter
+	shared.directive("@library")
+	sharedBlocks := imp.emitComponents(&shared, "")
+
+	for _, group := range imp.groups {
+		var w sdlWriter
+
+		var ops []*oaOp
+		for _, o := range imp.ops {
+			if o.group == group {
+				ops = append(ops, o)
+			}
+		}
+		var paths []string
+		for _, o := range ops {
+			paths = append(paths, o.path)
+		}
+		base := commonBase(paths)
+
+		imp.file = group
+		imp.includes = false
+
+		var calls []string
+		for _, o := range ops {
+			if call, ok := imp.call(o, base); ok {
+				calls = append(calls, call)
+			}
+		}
+
+		var local sdlWriter
+		imp.emitComponents(&local, group)
+
+		if base != "" {
+			w.directive("@base(%q)", base)
+		}
+		if imp.includes {
+			w.directive("@include(%q)", SharedFile)
+		}
+		if len(calls) > 0 {
+			w.block("type", "Calls", "", calls)
+		}
+		w.blocks = append(w.blocks, local.blocks...)
+
+		imp.res.Files = append(imp.res.Files, File{Path: group + ".sdl", Content: w.bytes()})
+	}
+
+	if sharedBlocks > 0 {
+		imp.res.Files = append(imp.res.Files, File{Path: SharedFile, Content: shared.bytes()})
+	}
+}
+
+// emitComponents writes the components declared in file (a group, or ""
+// for SharedFile) and returns how many blocks it wrote.
+func (imp *oaImporter) emitComponents(w *sdlWriter, file string) int {
+	imp.file = file
+	n := len(w.blocks)
+	for _, c := range imp.order {
+		if c.file != file {
+			continue
+		}
+		s := c.schema
+
+		if len(s.Enum) > 0 {
+			if c.alias == nil {
+				w.block("enum", c.name, "", c.values)
+			}
+			continue
+		}
+
+		if !s.isObject() {
+			t, _ := s.typ()
+			if t == "object" {
+				imp.res.warnf("%s: object without properties (a map or free-form object) is not supported", c.loc)
+			}
+			continue
+		}
+
+		if c.output {
+			w.block("type", c.name, "", imp.fields(s, c.loc, false))
+		}
+		if c.input {
+			w.block("input", imp.inputName(c), "", imp.fields(s, c.loc, true))
+		}
+	}
+	return len(w.blocks) - n
+}
+
+// checkEnums collects enum values, turning enums whose values aren't valid
+// SDL enum values into String aliases.
+func (imp *oaImporter) checkEnums() {
+	for _, c := range imp.order {
+		for _, v := range c.schema.Enum {
+			str, ok := v.(string)
+			if !ok || !identRe.MatchString(str) {
+				imp.res.warnf("%s: enum value %v is not a valid SDL enum value, written as String", c.loc, v)
+				c.alias = &oaSchema{Type: "string"}
+				c.values = nil
+				break
+			}
+			c.values = append(c.values, str)
+		}
+	}
+}
+
+// inputName is the SDL name of a component's input block. Components used
+// as both input and output get a separate input with an Input suffix.
+func (imp *oaImporter) inputName(c *oaComponent) string {
+	if c.output {
+		return c.name + "Input"
+	}
+	return c.name
+}
+
+// fields renders the properties of an object schema, including those of
+// its allOf parts.
+func (imp *oaImporter) fields(s *oaSchema, loc string, input bool) []string {
+	var lines []string
+	seen := make(map[string]bool)
+
+	var add func(s *oaSchema, loc string)
+	add = func(s *oaSchema, loc string) {
+		for i, part := range s.AllOf {
+			partLoc := fmt.Sprintf("%s.allOf[%d]", loc, i)
+			if part.Ref != "" {
+				c, err := imp.component(part.Ref)
+				if err != nil {
+					imp.res.warnf("%s: %v", partLoc, err)
+					continue
+				}
+				add(c.schema, c.loc)
+				continue
+			}
+			add(part, partLoc)
+		}
+
+		required := make(map[string]bool)
+		for _, r := range s.Required {
+			required[r] = true
+		}
+		for _, key := range s.Properties.Keys {
+			propLoc := loc + ".properties." + key
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if !identRe.MatchString(key) {
+				imp.res.warnf("%s: %q is not a valid SDL field name, skipped", propLoc, key)
+				continue
+			}
+			ref, err := imp.typeRef(s.Properties.Values[key], required[key], input)
+			if err != nil {
+				imp.res.warnf("%s: %v, skipped", propLoc, err)
+				continue
+			}
+			lines = append(lines, key+": "+ref)
+		}
+	}
+	add(s, loc)
+	return lines
+}
+
+// typeRef renders a schema as an SDL type reference from the current file.
+func (imp *oaImporter) typeRef(s *oaSchema, required, input bool) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	required = required && !s.nullable()
+
+	if s.Ref != "" {
+		c, err := imp.component(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		if c.alias != nil || (len(c.schema.Enum) == 0 && !c.schema.isObject()) {
+			return imp.aliasRef(c, required, input)
+		}
+		name := c.name
+		if input && len(c.schema.Enum) == 0 {
+			name = imp.inputName(c)
+		}
+		if c.file == "" && imp.file != "" {
+			imp.includes = true
+			name = sharedNamespace + "." + name
+		}
+		return typeString(name, required, false, false), nil
+	}
+
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "", fmt.Errorf("oneOf/anyOf has no SDL equivalent")
+	}
+
+	t, _ := s.typ()
+	switch t {
+	case "array":
+		if s.Items == nil {
+			return "", fmt.Errorf("array without items")
+		}
+		item, err := imp.typeRef(s.Items, true, input)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(item, "[") {
+			return "", fmt.Errorf("nested lists have no SDL equivalent")
+		}
+		itemRequired := strings.HasSuffix(item, "!")
+		return typeString(strings.TrimSuffix(item, "!"), required, true, itemRequired), nil
+	case "string":
+		if name, ok := imp.formats[s.Format]; ok {
+			return typeString(name, required, false, false), nil
+		}
+		return typeString("String", required, false, false), nil
+	case "integer":
+		return typeString("Int", required, false, false), nil
+	case "number":
+		return typeString("Float", required, false, false), nil
+	case "boolean":
+		return typeString("Boolean", required, false, false), nil
+	case "object", "":
+		return "", fmt.Errorf("object without properties (a map or free-form object) has no SDL equivalent")
+	}
+	return "", fmt.Errorf("type %q has no SDL equivalent", t)
+}
+
+// aliasRef renders a reference to a component that is neither an object
+// nor an enum by inlining its schema.
+func (imp *oaImporter) aliasRef(c *oaComponent, required, input bool) (string, error) {
+	if c.resolved {
+		return "", fmt.Errorf("%s refers to itself", c.key)
+	}
+	c.resolved = true
+	defer func() { c.resolved = false }()
+
+	s := c.alias
+	if s == nil {
+		s = c.schema
+	}
+	return imp.typeRef(s, required, input)
+}
+
+// commonBase is the longest run of leading static path segments shared by
+// every path, used as a group's @base.
+func commonBase(paths []string) string {
+	var common []string
+	for i, path := range paths {
+		var segs []string
+		for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+			if seg == "" || strings.HasPrefix(seg, "{") {
+				break
+			}
+			segs = append(segs, seg)
+		}
+		if i == 0 {
+			common = segs
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(segs) && common[n] == segs[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(common) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(common, "/")
+}
+
+// call renders an operation as a Calls entry.
+func (imp *oaImporter) call(o *oaOp, base string) (string, bool) {
+	var args []string
+	names := make(map[string]bool)
+	for _, p := range o.params {
+		ref, err := imp.typeRef(p.Schema, p.Required, true)
+		if err != nil {
+			imp.res.warnf("%s.parameters.%s: %v, operation skipped", o.loc, p.Name, err)
+			return "", false
+		}
+		names[p.Name] = true
+		args = append(args, p.Name+": "+ref)
+	}
+
+	if o.body != nil {
+		ref, err := imp.typeRef(o.body, o.bodyReq, true)
+		if err != nil {
+			imp.res.warnf("%s.requestBody: %v, operation skipped", o.loc, err)
+			return "", false
+		}
+		name := "input"
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("input%d", i)
+		}
+		args = append(args, name+": "+ref)
+	}
+
+	returns := "Boolean"
+	if o.response != nil {
+		ref, err := imp.typeRef(o.response, true, false)
+		if err != nil {
+			imp.res.warnf("%s: response %v, returns Boolean", o.loc, err)
+		} else {
+			returns = ref
+		}
+	}
+
+	path := strings.TrimPrefix(o.path, base)
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s(%s): %s @%s(%q)", o.name, strings.Join(args, ", "), returns, o.method, path), true
+}
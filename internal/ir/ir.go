@@ -0,0 +1,342 @@
+package ir
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/parser"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// Version is the IR format version. It is bumped whenever a change would
+// break existing consumers; adding fields does not bump it.
+const Version = 1
+
+// Document is the fully resolved, serializable form of a set of schemas.
+// It is what `restgen ir` prints and what plugins receive.
+type Document struct {
+	Version int      `json:"version"`
+	Config  Config   `json:"config"`
+	Schemas []Schema `json:"schemas"`
+}
+
+// Config is the subset of restgen.yaml that affects code generation.
+type Config struct {
+	Target  string            `json:"target,omitempty"` // set when generating one of several targets
+	Package string            `json:"package"`
+	Output  string            `json:"output"`
+	Models  string            `json:"models,omitempty"`
+	Router  string            `json:"router"`
+	Scalars map[string]Scalar `json:"scalars"`
+}
+
+// Scalar is the Go mapping of an SDL scalar.
+type Scalar struct {
+	GoType string `json:"goType"`           // qualified Go type (e.g., "decimal.Decimal")
+	Import string `json:"import,omitempty"` // import path of its package
+	Parse  string `json:"parse,omitempty"`  // func(string) (T, error)
+
+	OpenAPIFormat string `json:"openapiFormat,omitempty"` // e.g., "email"
+}
+
+// ScalarDef is a scalar declared in SDL.
+type ScalarDef struct {
+	Name          string `json:"name"`
+	GoType        string `json:"goType"`           // qualified Go type (e.g., "decimal.Decimal")
+	Import        string `json:"import,omitempty"` // import path of its package
+	OpenAPIFormat string `json:"openapiFormat,omitempty"`
+	Pos           Pos    `json:"pos"`
+}
+
+// Schema is a single SDL file. Included files appear as schemas of their
+// own, with Root set to false.
+type Schema struct {
+	File       string      `json:"file"`
+	Root       bool        `json:"root"`              // matched by cfg.Schemas rather than only included
+	Library    bool        `json:"library,omitempty"` // marked @library: models only
+	Base       string      `json:"base,omitempty"`
+	Handler    string      `json:"handler,omitempty"` // Go handler type (e.g., "ContactsHandler"); empty for @library
+	Models     string      `json:"models,omitempty"`
+	Directives []Directive `json:"directives,omitempty"`
+	Includes   []Include   `json:"includes,omitempty"`
+	Scalars    []ScalarDef `json:"scalars,omitempty"`
+	Calls      []Call      `json:"calls,omitempty"`
+	Types      []Type      `json:"types,omitempty"`
+	Inputs     []Type      `json:"inputs,omitempty"`
+	Enums      []Enum      `json:"enums,omitempty"`
+}
+
+// Pos is a 1-based line and column in the schema's file.
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Directive is a header directive such as @base("/v1/contacts").
+type Directive struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+	Pos  Pos      `json:"pos"`
+}
+
+// Include is an @include, resolved to the file it refers to.
+type Include struct {
+	Path      string `json:"path"` // as written in the SDL
+	File      string `json:"file"` // File of the included Schema
+	Namespace string `json:"namespace"`
+	Models    string `json:"models,omitempty"`
+	Pos       Pos    `json:"pos"`
+}
+
+// TypeRef is a reference to a scalar, type, input or enum.
+type TypeRef struct {
+	Name         string `json:"name"`                // type name without namespace
+	Namespace    string `json:"namespace,omitempty"` // include namespace, if any
+	Required     bool   `json:"required"`
+	List         bool   `json:"list"`
+	ItemRequired bool   `json:"itemRequired"` // items are non-nullable: [Type!]
+}
+
+// Call is an endpoint from the Calls block.
+type Call struct {
+	Name    string  `json:"name"`
+	Handler string  `json:"handler"` // Go handler method name
+	Method  string  `json:"method"`
+	Path    string  `json:"path"`
+	Args    []Arg   `json:"args,omitempty"`
+	Returns TypeRef `json:"returns"`
+	Pos     Pos     `json:"pos"`
+}
+
+// Arg is a call argument and where it is bound from.
+type Arg struct {
+	Name   string  `json:"name"`
+	Type   TypeRef `json:"type"`
+	Source string  `json:"source"` // "path", "query" or "body"
+	Pos    Pos     `json:"pos"`
+}
+
+// Type is a type or input definition.
+type Type struct {
+	Name    string  `json:"name"`
+	Fields  []Field `json:"fields"`
+	GoModel string  `json:"goModel,omitempty"` // existing Go type it is bound to; not generated
+	Pos     Pos     `json:"pos"`
+}
+
+// Field is a field of a type or input.
+type Field struct {
+	Name string  `json:"name"`
+	Type TypeRef `json:"type"`
+	Pos  Pos     `json:"pos"`
+}
+
+// Enum is an enum definition.
+type Enum struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+	Pos    Pos      `json:"pos"`
+}
+
+// Build parses schemaFiles (and everything they include) with p and returns
+// the resolved document. cfg must be a single target (see Config.Resolve).
+// Root schemas without @models get the config's default models package, as
+// they do during generation; a target with its own models package replaces
+// @models everywhere.
+func Build(cfg *config.Config, p *parser.Parser, schemaFiles []string) (*Document, error) {
+	doc := &Document{
+		Version: Version,
+		Config: Config{
+			Target:  cfg.Name,
+			Package: cfg.Package,
+			Output:  cfg.Output,
+			Models:  cfg.Models.Package,
+			Router:  cfg.Router,
+			Scalars: make(map[string]Scalar, len(cfg.Scalars)),
+		},
+	}
+	for name, sc := range cfg.Scalars {
+		doc.Config.Scalars[name] = Scalar{
+			GoType:        sc.GoType(),
+			Import:        sc.Import,
+			Parse:         sc.Parse,
+			OpenAPIFormat: sc.OpenAPIFormat,
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	var add func(path string, root bool) error
+	add = func(path string, root bool) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if seen[abs] {
+			return nil
+		}
+		seen[abs] = true
+
+		s, err := p.ParseFile(path)
+		if err != nil {
+			return err
+		}
+
+		out := convertSchema(s, displayPath(path), root)
+		if root || cfg.ModelsOverride {
+			out.Models = cfg.ModelsFor(out.Models)
+		}
+		if cfg.ModelsOverride {
+			for i := range out.Includes {
+				out.Includes[i].Models = cfg.Models.Package
+			}
+		}
+		doc.Schemas = append(doc.Schemas, out)
+
+		for _, inc := range s.Includes {
+			if err := add(inc.Resolved, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Mark every root first so a root that is also included elsewhere
+	// is still reported as a root.
+	for _, f := range schemaFiles {
+		if err := add(f, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func convertSchema(s *schema.Schema, file string, root bool) Schema {
+	out := Schema{
+		File:    file,
+		Root:    root,
+		Library: s.Library,
+		Base:    s.Base,
+		Models:  s.Models,
+	}
+	if !s.Library {
+		out.Handler = s.HandlerName() + "Handler"
+	}
+
+	for _, d := range s.Directives {
+		out.Directives = append(out.Directives, Directive{Name: d.Name, Args: d.Args, Pos: Pos(d.Pos)})
+	}
+
+	for _, inc := range s.Includes {
+		out.Includes = append(out.Includes, Include{
+			Path:      inc.Path,
+			File:      displayPath(inc.Resolved),
+			Namespace: inc.Namespace,
+			Models:    inc.Models,
+			Pos:       Pos(inc.Pos),
+		})
+	}
+
+	for _, def := range s.Scalars {
+		mapping := config.ScalarFromString(def.GoType)
+		out.Scalars = append(out.Scalars, ScalarDef{
+			Name:          def.Name,
+			GoType:        mapping.GoType(),
+			Import:        mapping.Import,
+			OpenAPIFormat: def.Format,
+			Pos:           Pos(def.Pos),
+		})
+	}
+
+	for _, c := range s.Calls {
+		call := Call{
+			Name:    c.Name,
+			Handler: c.HandlerName(),
+			Method:  c.Method,
+			Path:    c.Path,
+			Returns: typeRef(c.ReturnType, c.ReturnRequired, c.ReturnIsList, c.ReturnItemRequired),
+			Pos:     Pos(c.Pos),
+		}
+
+		pathParams := c.PathParamSet()
+		for _, a := range c.Args {
+			source := "query"
+			if pathParams[a.Name] {
+				source = "path"
+			} else if c.IsBodyMethod() {
+				source = "body"
+			}
+			call.Args = append(call.Args, Arg{
+				Name:   a.Name,
+				Type:   typeRef(a.Type, a.Required, a.IsList, a.ItemRequired),
+				Source: source,
+				Pos:    Pos(a.Pos),
+			})
+		}
+
+		out.Calls = append(out.Calls, call)
+	}
+
+	for _, t := range s.Types {
+		out.Types = append(out.Types, Type{Name: t.Name, Fields: convertFields(t.Fields), GoModel: t.GoModel, Pos: Pos(t.Pos)})
+	}
+	for _, t := range s.Inputs {
+		out.Inputs = append(out.Inputs, Type{Name: t.Name, Fields: convertFields(t.Fields), GoModel: t.GoModel, Pos: Pos(t.Pos)})
+	}
+	for _, e := range s.Enums {
+		out.Enums = append(out.Enums, Enum{Name: e.Name, Values: e.Values, Pos: Pos(e.Pos)})
+	}
+
+	return out
+}
+
+func convertFields(fields []schema.Field) []Field {
+	out := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, Field{Name: f.Name, Type: typeRef(f.Type, f.Required, f.IsList, f.ItemRequired), Pos: Pos(f.Pos)})
+	}
+	return out
+}
+
+// String renders t as it is written in SDL.
+func (t TypeRef) String() string {
+	s := t.Name
+	if t.Namespace != "" {
+		s = t.Namespace + "." + s
+	}
+	if t.List {
+		if t.ItemRequired {
+			s += "!"
+		}
+		s = "[" + s + "]"
+	}
+	if t.Required {
+		s += "!"
+	}
+	return s
+}
+
+func typeRef(ref string, required, isList, itemRequired bool) TypeRef {
+	ns, name := schema.ParseTypeRef(ref)
+	return TypeRef{Name: name, Namespace: ns, Required: required, List: isList, ItemRequired: itemRequired}
+}
+
+// displayPath makes path relative to the working directory when it lies
+// beneath it, so the IR doesn't depend on where the repo is checked out.
+func displayPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || rel == ".." || len(rel) > 2 && rel[:3] == ".."+string(filepath.Separator) {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,246 @@
+// Package lint checks parsed schemas against style rules. Every rule has an
+// ID and a default severity that restgen.yaml can override, and a finding
+// can be suppressed with a `# restgen:ignore rule-id` comment on its line
+// or on the line above.
+package lint
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// File is a schema to lint.
+type File struct {
+	Path   string // as reported in diagnostics
+	Abs    string // absolute path, matched against schema.Include.Resolved
+	Source []byte // SDL source, for restgen:ignore comments
+	Schema *schema.Schema
+}
+
+// Diagnostic is a single finding.
+type Diagnostic struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // config.SeverityError, SeverityWarning or SeverityInfo
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Rule is a lint rule.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    string // default severity
+
+	check func(l *linter, f *File)
+}
+
+// Rules returns every rule, sorted by ID.
+func Rules() []Rule {
+	rules := []Rule{
+		{"call-name-verb", "Call names are camelCase and start with a verb", config.SeverityWarning, checkCallNames},
+		{"path-kebab-case", "Static path segments are kebab-case", config.SeverityWarning, checkKebabPaths},
+		{"path-plural", "Path segments followed by a parameter name a collection and are plural", config.SeverityWarning, checkPluralPaths},
+		{"delete-return", "@delete calls return DeleteResult or Boolean", config.SeverityWarning, checkDeleteReturns},
+		{"input-suffix", "Input names end in Input", config.SeverityWarning, checkInputSuffix},
+		{"list-pagination", "List endpoints take pagination arguments", config.SeverityWarning, checkPagination},
+		{"unused-type", "Types and inputs are used by a call", config.SeverityWarning, checkUnused},
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Run lints files with the severities in cfg. files should include every
+// file the others include, so usage can be followed across them.
+func Run(files []File, cfg config.LintConfig) ([]Diagnostic, error) {
+	rules := Rules()
+	if err := validate(rules, cfg); err != nil {
+		return nil, err
+	}
+
+	l := &linter{byAbs: make(map[string]*File)}
+	for i := range files {
+		l.byAbs[files[i].Abs] = &files[i]
+	}
+
+	for _, r := range rules {
+		sev := r.Severity
+		if s, ok := cfg.Rules[r.ID]; ok {
+			sev = s
+		}
+		if sev == config.SeverityOff {
+			continue
+		}
+		l.rule, l.severity = r.ID, sev
+		for i := range files {
+			r.check(l, &files[i])
+		}
+	}
+
+	var out []Diagnostic
+	for _, d := range l.diags {
+		if !l.ignored(d) {
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+	return out, nil
+}
+
+// validate checks that cfg only names rules that exist, with known
+// severities.
+func validate(rules []Rule, cfg config.LintConfig) error {
+	var ids []string
+	for _, r := range rules {
+		ids = append(ids, r.ID)
+	}
+
+	var errs []string
+	for _, id := range slices.Sorted(maps.Keys(cfg.Rules)) {
+		if !slices.Contains(ids, id) {
+			errs = append(errs, fmt.Sprintf("unknown lint rule %q (rules: %s)", id, strings.Join(ids, ", ")))
+			continue
+		}
+		switch sev := cfg.Rules[id]; sev {
+		case config.SeverityError, config.SeverityWarning, config.SeverityInfo, config.SeverityOff:
+		default:
+			errs = append(errs, fmt.Sprintf("lint rule %s: unknown severity %q (want error, warning, info or off)", id, sev))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("lint.rules: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == config.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type linter struct {
+	byAbs map[string]*File
+	diags []Diagnostic
+
+	// Rule being run
+	rule     string
+	severity string
+
+	used    map[string]bool           // see usage
+	ignores map[string]map[int]ignore // file -> line -> suppressions
+}
+
+// ignore is a restgen:ignore comment. A comment alone on its line also
+// covers the next line.
+type ignore struct {
+	rules []string
+	next  bool
+}
+
+func (l *linter) report(f *File, pos schema.Pos, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Rule:     l.rule,
+		Severity: l.severity,
+		File:     f.Path,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+var ignoreRe = regexp.MustCompile(`#\s*restgen:ignore\s+([\w\s,-]+)`)
+
+// ignored reports whether d is suppressed by a restgen:ignore comment on
+// its line, or alone on the line above.
+func (l *linter) ignored(d Diagnostic) bool {
+	if l.ignores == nil {
+		l.ignores = make(map[string]map[int]ignore)
+		for _, f := range l.byAbs {
+			lines := make(map[int]ignore)
+			for i, line := range strings.Split(string(f.Source), "\n") {
+				m := ignoreRe.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				lines[i+1] = ignore{
+					rules: strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }),
+					next:  strings.HasPrefix(strings.TrimSpace(line), "#"),
+				}
+			}
+			l.ignores[f.Path] = lines
+		}
+	}
+
+	lines := l.ignores[d.File]
+	matches := func(ig ignore) bool {
+		for _, id := range ig.rules {
+			if id == d.Rule {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(lines[d.Line]) {
+		return true
+	}
+	above := lines[d.Line-1]
+	return above.next && matches(above)
+}
+
+// resolve returns the file and name a type reference in f points at.
+func (l *linter) resolve(f *File, ref string) (*File, string) {
+	ns, name := schema.ParseTypeRef(ref)
+	if ns == "" {
+		return f, name
+	}
+	for _, inc := range f.Schema.Includes {
+		if inc.Namespace == ns {
+			return l.byAbs[inc.Resolved], name
+		}
+	}
+	return nil, name
+}
+
+// fields returns the fields of the type or input name in f.
+func fields(f *File, name string) ([]schema.Field, bool) {
+	for _, t := range f.Schema.Types {
+		if t.Name == name {
+			return t.Fields, true
+		}
+	}
+	for _, t := range f.Schema.Inputs {
+		if t.Name == name {
+			return t.Fields, true
+		}
+	}
+	return nil, false
+}
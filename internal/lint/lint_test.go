@@ -0,0 +1,72 @@
+package lint
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+func TestRunValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   map[string]string
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "every severity", rules: map[string]string{
+			"unused-type": config.SeverityError, "path-plural": config.SeverityOff,
+			"input-suffix": config.SeverityInfo, "delete-return": config.SeverityWarning,
+		}},
+		{name: "unknown rule", rules: map[string]string{"unused-types": "off"}, wantErr: `unknown lint rule "unused-types"`},
+		{name: "unknown severity", rules: map[string]string{"unused-type": "fatal"}, wantErr: `unknown severity "fatal"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(nil, config.LintConfig{Rules: tt.rules})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// The JSON Schema lists the rules for editor completion; keep it in sync.
+func TestRulesInConfigSchema(t *testing.T) {
+	var doc struct {
+		Properties struct {
+			Lint struct {
+				Properties struct {
+					Rules struct {
+						Properties map[string]json.RawMessage `json:"properties"`
+					} `json:"rules"`
+				} `json:"properties"`
+			} `json:"lint"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(config.JSONSchema, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for _, r := range Rules() {
+		want = append(want, r.ID)
+	}
+	var got []string
+	for id := range doc.Properties.Lint.Properties.Rules.Properties {
+		got = append(got, id)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("schema lists rules %v, want %v", got, want)
+	}
+}
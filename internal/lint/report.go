@@ -0,0 +1,130 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/config"
+)
+
+// Text renders diagnostics one per line, followed by a summary.
+func Text(diags []Diagnostic) string {
+	var b strings.Builder
+	counts := make(map[string]int)
+	for _, d := range diags {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+		counts[d.Severity]++
+	}
+
+	if len(diags) == 0 {
+		b.WriteString("No problems found.\n")
+		return b.String()
+	}
+	var parts []string
+	for _, sev := range []string{config.SeverityError, config.SeverityWarning, config.SeverityInfo} {
+		if n := counts[sev]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s%s", n, sev, pluralS(n)))
+		}
+	}
+	fmt.Fprintf(&b, "\n%s\n", strings.Join(parts, ", "))
+	return b.String()
+}
+
+func pluralS(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// SARIF renders diagnostics as a SARIF 2.1.0 log, for code scanning tools.
+// Every rule is listed with its severity under cfg.
+func SARIF(diags []Diagnostic, cfg config.LintConfig, version string) ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID                   string  `json:"id"`
+		ShortDescription     message `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level   string `json:"level"`
+			Enabled bool   `json:"enabled"`
+		} `json:"defaultConfiguration"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine   int `json:"startLine"`
+				StartColumn int `json:"startColumn,omitempty"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	var rules []rule
+	for _, r := range Rules() {
+		sev := r.Severity
+		if s, ok := cfg.Rules[r.ID]; ok {
+			sev = s
+		}
+		var sr rule
+		sr.ID = r.ID
+		sr.ShortDescription.Text = r.Description
+		sr.DefaultConfiguration.Level = sarifLevel(sev)
+		sr.DefaultConfiguration.Enabled = sev != config.SeverityOff
+		rules = append(rules, sr)
+	}
+
+	results := []result{}
+	for _, d := range diags {
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = d.File
+		loc.PhysicalLocation.Region.StartLine = d.Line
+		loc.PhysicalLocation.Region.StartColumn = d.Column
+		results = append(results, result{
+			RuleID:    d.Rule,
+			Level:     sarifLevel(d.Severity),
+			Message:   message{d.Message},
+			Locations: []location{loc},
+		})
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":           "restgen",
+					"version":        version,
+					"informationUri": "https://github.com/borderlesshq/restgen",
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case config.SeverityError:
+		return "error"
+	case config.SeverityInfo:
+		return "note"
+	case config.SeverityOff:
+		return "none"
+	}
+	return "warning"
+}
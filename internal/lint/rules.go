@@ -0,0 +1,249 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// verbs are the words a call name may start with.
+var verbs = map[string]bool{
+	"accept": true, "add": true, "apply": true, "approve": true, "archive": true,
+	"assign": true, "attach": true, "batch": true, "bulk": true, "calculate": true,
+	"cancel": true, "check": true, "close": true, "complete": true, "confirm": true,
+	"copy": true, "count": true, "create": true, "decline": true, "delete": true,
+	"detach": true, "disable": true, "download": true, "enable": true, "estimate": true,
+	"execute": true, "export": true, "fetch": true, "find": true, "follow": true,
+	"generate": true, "get": true, "import": true, "invite": true, "link": true,
+	"list": true, "lock": true, "login": true, "logout": true, "lookup": true,
+	"mark": true, "merge": true, "move": true, "patch": true, "preview": true,
+	"process": true, "publish": true, "query": true, "refresh": true, "register": true,
+	"reject": true, "remove": true, "replace": true, "resend": true, "reset": true,
+	"restore": true, "retry": true, "revoke": true, "run": true, "search": true,
+	"send": true, "set": true, "share": true, "start": true, "stop": true,
+	"submit": true, "subscribe": true, "sync": true, "toggle": true, "unassign": true,
+	"unfollow": true, "unlink": true, "unlock": true, "unpublish": true, "unsubscribe": true,
+	"update": true, "upload": true, "upsert": true, "validate": true, "verify": true,
+}
+
+var (
+	camelRe     = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	leadWordRe  = regexp.MustCompile(`^[a-z]+`)
+	kebabRe     = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	versionRe   = regexp.MustCompile(`^v[0-9]+$`)
+	paginations = []string{"limit", "first", "last", "pageSize", "perPage", "size", "page", "offset", "cursor", "after", "before"}
+)
+
+func checkCallNames(l *linter, f *File) {
+	for _, c := range f.Schema.Calls {
+		if !camelRe.MatchString(c.Name) {
+			l.report(f, c.Pos, "call name %s is not camelCase", c.Name)
+			continue
+		}
+		if !verbs[leadWordRe.FindString(c.Name)] {
+			l.report(f, c.Pos, "call name %s does not start with a verb such as get, list, create, update or delete", c.Name)
+		}
+	}
+}
+
+// segment is a static path segment and whether it comes from @base.
+type segment struct {
+	text   string
+	base   bool
+	param  bool
+	before bool // directly followed by a parameter
+}
+
+func segments(s *schema.Schema, c schema.Call) []segment {
+	var segs []segment
+	add := func(path string, base bool) {
+		for _, part := range strings.Split(path, "/") {
+			if part == "" {
+				continue
+			}
+			param := strings.HasPrefix(part, "{")
+			if param && len(segs) > 0 {
+				segs[len(segs)-1].before = true
+			}
+			segs = append(segs, segment{text: part, base: base, param: param})
+		}
+	}
+	add(s.Base, true)
+	add(c.Path, false)
+	return segs
+}
+
+// basePos is where @base is declared in s.
+func basePos(s *schema.Schema) schema.Pos {
+	for _, d := range s.Directives {
+		if d.Name == "base" {
+			return d.Pos
+		}
+	}
+	return schema.Pos{Line: 1, Column: 1}
+}
+
+// reportPath reports a problem with a path segment at the call, or once at
+// @base when the segment comes from it.
+func reportPath(l *linter, f *File, c schema.Call, seg segment, seen map[string]bool, format string, args ...any) {
+	if seg.base {
+		key := l.rule + " " + seg.text
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		l.report(f, basePos(f.Schema), format, args...)
+		return
+	}
+	l.report(f, c.Pos, format, args...)
+}
+
+func checkKebabPaths(l *linter, f *File) {
+	seen := make(map[string]bool)
+	for _, c := range f.Schema.Calls {
+		for _, seg := range segments(f.Schema, c) {
+			if !seg.param && !kebabRe.MatchString(seg.text) {
+				reportPath(l, f, c, seg, seen, "path segment %q is not kebab-case", seg.text)
+			}
+		}
+	}
+}
+
+func checkPluralPaths(l *linter, f *File) {
+	seen := make(map[string]bool)
+	for _, c := range f.Schema.Calls {
+		for _, seg := range segments(f.Schema, c) {
+			if seg.param || !seg.before || versionRe.MatchString(seg.text) || plural(seg.text) {
+				continue
+			}
+			reportPath(l, f, c, seg, seen, "path segment %q names a collection and should be plural", seg.text)
+		}
+	}
+}
+
+// plural guesses whether the last word of a kebab-case segment is plural.
+func plural(seg string) bool {
+	word := seg[strings.LastIndex(seg, "-")+1:]
+	switch word {
+	case "people", "children", "data", "media", "news", "series", "men", "women":
+		return true
+	}
+	return strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us")
+}
+
+func checkDeleteReturns(l *linter, f *File) {
+	for _, c := range f.Schema.Calls {
+		if c.Method != "DELETE" {
+			continue
+		}
+		_, name := schema.ParseTypeRef(c.ReturnType)
+		if (name != "DeleteResult" && name != "Boolean") || c.ReturnIsList {
+			l.report(f, c.Pos, "@delete call %s returns %s; return DeleteResult or Boolean", c.Name, c.ReturnType)
+		}
+	}
+}
+
+func checkInputSuffix(l *linter, f *File) {
+	for _, in := range f.Schema.Inputs {
+		if !strings.HasSuffix(in.Name, "Input") {
+			l.report(f, in.Pos, "input %s should be named %sInput", in.Name, in.Name)
+		}
+	}
+}
+
+func checkPagination(l *linter, f *File) {
+	for _, c := range f.Schema.Calls {
+		if c.Method != "GET" || !c.ReturnIsList && !strings.HasPrefix(c.Name, "list") {
+			continue
+		}
+		if !paginated(l, f, c) {
+			l.report(f, c.Pos, "list endpoint %s has no pagination arguments (%s)", c.Name, strings.Join(paginations[:4], ", ")+", ...")
+		}
+	}
+}
+
+// paginated reports whether c takes a pagination argument, directly or as
+// a field of an input argument.
+func paginated(l *linter, f *File, c schema.Call) bool {
+	isPagination := func(name string) bool {
+		for _, p := range paginations {
+			if strings.EqualFold(name, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, a := range c.Args {
+		if isPagination(a.Name) {
+			return true
+		}
+		tf, name := l.resolve(f, a.Type)
+		if tf == nil {
+			continue
+		}
+		fs, _ := fields(tf, name)
+		for _, field := range fs {
+			if isPagination(field.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkUnused reports types and inputs no call reaches, following fields
+// and includes. It marks usage from every file once, on the first call.
+func checkUnused(l *linter, f *File) {
+	used := l.usage()
+	for _, t := range f.Schema.Types {
+		if !used[f.Abs+"#"+t.Name] {
+			l.report(f, t.Pos, "type %s is not used by any call", t.Name)
+		}
+	}
+	for _, t := range f.Schema.Inputs {
+		if !used[f.Abs+"#"+t.Name] {
+			l.report(f, t.Pos, "input %s is not used by any call", t.Name)
+		}
+	}
+}
+
+// usage returns the types and inputs reachable from any call, keyed by
+// "<abs path>#<name>".
+func (l *linter) usage() map[string]bool {
+	if l.used != nil {
+		return l.used
+	}
+	l.used = make(map[string]bool)
+
+	var mark func(f *File, ref string)
+	mark = func(f *File, ref string) {
+		tf, name := l.resolve(f, ref)
+		if tf == nil {
+			return
+		}
+		key := tf.Abs + "#" + name
+		if l.used[key] {
+			return
+		}
+		fs, ok := fields(tf, name)
+		if !ok {
+			return
+		}
+		l.used[key] = true
+		for _, field := range fs {
+			mark(tf, field.Type)
+		}
+	}
+
+	for _, f := range l.byAbs {
+		for _, c := range f.Schema.Calls {
+			mark(f, c.ReturnType)
+			for _, a := range c.Args {
+				mark(f, a.Type)
+			}
+		}
+	}
+	return l.used
+}
@@ -0,0 +1,321 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/parser"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// analysis is an open document, parsed together with the files it
+// includes and the restgen.yaml that applies to it.
+type analysis struct {
+	path     string
+	text     string
+	lines    []string
+	readFile func(path string) ([]byte, error)
+	parser   *parser.Parser
+	cfg      *config.Config // with the schema's scalars merged in
+
+	schema *schema.Schema // nil if the document doesn't parse
+	err    error
+}
+
+func (s *Server) analyze(path string) *analysis {
+	text := s.docs[path]
+	a := &analysis{
+		path:     path,
+		text:     text,
+		lines:    splitLines(text),
+		readFile: s.readFile,
+		parser:   parser.NewWithReader(s.readFile),
+		cfg:      s.config(filepath.Dir(path)),
+	}
+	a.schema, a.err = a.parser.ParseFile(path)
+	if a.schema != nil {
+		a.cfg = a.cfg.ForSchema(a.schema)
+	}
+	return a
+}
+
+// config loads the restgen.yaml in dir or the nearest parent directory,
+// falling back to the defaults. With targets, the first one applies.
+func (s *Server) config(dir string) *config.Config {
+	for {
+		path := filepath.Join(dir, "restgen.yaml")
+		if _, err := os.Stat(path); err == nil {
+			cfg, err := config.Load(path)
+			if err != nil {
+				s.logger.Printf("%v", err)
+				break
+			}
+			if targets, err := cfg.Resolve(); err == nil && len(targets) > 0 {
+				return targets[0]
+			}
+			return cfg
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return config.DefaultConfig()
+}
+
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// line returns the 0-based line n, or "" if there is none.
+func (a *analysis) line(n int) string {
+	if n < 0 || n >= len(a.lines) {
+		return ""
+	}
+	return a.lines[n]
+}
+
+// definition is where a type, input, enum or scalar is declared.
+type definition struct {
+	path   string
+	schema *schema.Schema
+	kind   string // "type", "input", "enum" or "scalar"
+	name   string
+	pos    schema.Pos
+}
+
+// include returns the schema included under namespace ns.
+func (a *analysis) include(s *schema.Schema, ns string) (*schema.Schema, *schema.Include) {
+	for i, inc := range s.Includes {
+		if inc.Namespace == ns {
+			is, err := a.parser.ParseFile(inc.Resolved)
+			if err != nil {
+				return nil, &s.Includes[i]
+			}
+			return is, &s.Includes[i]
+		}
+	}
+	return nil, nil
+}
+
+// lookup finds the declaration a type reference in the document points at.
+func (a *analysis) lookup(ref string) (definition, bool) {
+	if a.schema == nil {
+		return definition{}, false
+	}
+	ns, name := schema.ParseTypeRef(ref)
+	if ns != "" {
+		is, inc := a.include(a.schema, ns)
+		if is == nil {
+			return definition{}, false
+		}
+		return declared(inc.Resolved, is, name)
+	}
+	if d, ok := declared(a.path, a.schema, name); ok {
+		return d, true
+	}
+	// Scalars declared by included files are referenced unqualified
+	return a.includedScalar(a.schema, name, make(map[string]bool))
+}
+
+func (a *analysis) includedScalar(s *schema.Schema, name string, seen map[string]bool) (definition, bool) {
+	for _, inc := range s.Includes {
+		if seen[inc.Resolved] {
+			continue
+		}
+		seen[inc.Resolved] = true
+		is, err := a.parser.ParseFile(inc.Resolved)
+		if err != nil {
+			continue
+		}
+		for _, sc := range is.Scalars {
+			if sc.Name == name {
+				return definition{inc.Resolved, is, "scalar", name, sc.Pos}, true
+			}
+		}
+		if d, ok := a.includedScalar(is, name, seen); ok {
+			return d, true
+		}
+	}
+	return definition{}, false
+}
+
+// declared finds name among the declarations of s, the schema of path.
+func declared(path string, s *schema.Schema, name string) (definition, bool) {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return definition{path, s, "type", name, t.Pos}, true
+		}
+	}
+	for _, t := range s.Inputs {
+		if t.Name == name {
+			return definition{path, s, "input", name, t.Pos}, true
+		}
+	}
+	for _, e := range s.Enums {
+		if e.Name == name {
+			return definition{path, s, "enum", name, e.Pos}, true
+		}
+	}
+	for _, sc := range s.Scalars {
+		if sc.Name == name {
+			return definition{path, s, "scalar", name, sc.Pos}, true
+		}
+	}
+	return definition{}, false
+}
+
+// fileLines returns the lines of path, from the open documents or disk.
+func (a *analysis) fileLines(path string) []string {
+	if path == a.path {
+		return a.lines
+	}
+	data, err := a.readFile(path)
+	if err != nil {
+		return nil
+	}
+	return splitLines(string(data))
+}
+
+// nameRange returns the range of name at or after pos on its line in
+// lines, or an empty range at pos if it isn't there.
+func nameRange(lines []string, pos schema.Pos, name string) Range {
+	line := ""
+	if pos.Line >= 1 && pos.Line <= len(lines) {
+		line = lines[pos.Line-1]
+	}
+	start := min(max(pos.Column-1, 0), len(line))
+	if i := wordIndex(line[start:], name); i >= 0 {
+		start += i
+		return lineRange(line, pos.Line-1, start, start+len(name))
+	}
+	return lineRange(line, pos.Line-1, start, start)
+}
+
+// wordIndex is strings.Index for a whole identifier.
+func wordIndex(s, word string) int {
+	if word == "" {
+		return -1
+	}
+	re := regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(word) + `($|[^\w.])`)
+	m := re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return -1
+	}
+	return m[3]
+}
+
+// lineRange converts byte offsets in line n to a Range.
+func lineRange(line string, n, start, end int) Range {
+	return Range{
+		Start: Position{n, toUTF16(line, start)},
+		End:   Position{n, toUTF16(line, end)},
+	}
+}
+
+// toUTF16 converts a byte offset in line to UTF-16 code units.
+func toUTF16(line string, offset int) int {
+	n := 0
+	for i, r := range line {
+		if i >= offset {
+			break
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// fromUTF16 converts UTF-16 code units in line to a byte offset.
+func fromUTF16(line string, units int) int {
+	n := 0
+	for i, r := range line {
+		if n >= units {
+			return i
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// wordAt returns the identifier, possibly namespaced, under p, with its
+// byte offsets in the line.
+func (a *analysis) wordAt(p Position) (word string, start, end int) {
+	line := a.line(p.Line)
+	col := fromUTF16(line, p.Character)
+	start, end = col, col
+	for start > 0 && (isNameByte(line[start-1]) || line[start-1] == '.') {
+		start--
+	}
+	for end < len(line) && (isNameByte(line[end]) || line[end] == '.') {
+		end++
+	}
+	word = strings.Trim(line[start:end], ".")
+	if word == "" || !utf8.ValidString(word) {
+		return "", col, col
+	}
+	start += strings.Index(line[start:end], word)
+	return word, start, start + len(word)
+}
+
+var includeRe = regexp.MustCompile(`@include\s*\(\s*"([^"]*)"`)
+
+// includeAt returns the path of the @include string under p.
+func (a *analysis) includeAt(p Position) (string, bool) {
+	line := a.line(p.Line)
+	col := fromUTF16(line, p.Character)
+	for _, m := range includeRe.FindAllStringSubmatchIndex(line, -1) {
+		if col >= m[2]-1 && col <= m[3]+1 {
+			return line[m[2]:m[3]], true
+		}
+	}
+	return "", false
+}
+
+// blockEnd returns the 0-based line of the brace closing the declaration
+// at pos, or pos's own line if it has no body.
+func blockEnd(lines []string, pos schema.Pos) int {
+	depth := 0
+	opened := false
+	for n := pos.Line - 1; n < len(lines); n++ {
+		line := lines[n]
+		if n == pos.Line-1 {
+			line = line[min(max(pos.Column-1, 0), len(line)):]
+		} else if !opened && !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			// A bodiless declaration such as `type X @goModel("...")`
+			return pos.Line - 1
+		}
+		for i := 0; i < len(line); i++ {
+			switch line[i] {
+			case '#':
+				i = len(line)
+			case '"':
+				if j := strings.IndexByte(line[i+1:], '"'); j >= 0 {
+					i += j + 1
+				}
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+				if opened && depth == 0 {
+					return n
+				}
+			}
+		}
+	}
+	return len(lines) - 1
+}
@@ -0,0 +1,445 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/emitter"
+	"github.com/borderlesshq/restgen/internal/routes"
+	"github.com/borderlesshq/restgen/internal/schema"
+	"github.com/borderlesshq/restgen/internal/sdlfmt"
+)
+
+func (a *analysis) diagnostics() []Diagnostic {
+	diags := []Diagnostic{}
+	if a.err != nil {
+		return append(diags, a.parseError())
+	}
+
+	for _, ref := range a.references() {
+		ns, name := schema.ParseTypeRef(ref.ref)
+		if _, ok := a.lookup(ref.ref); ok {
+			continue
+		}
+		var msg string
+		if ns != "" {
+			if _, inc := a.include(a.schema, ns); inc == nil {
+				msg = fmt.Sprintf("no @include with namespace %q", ns)
+			} else if name == "" {
+				msg = fmt.Sprintf("expected a type name after %s.", ns)
+			} else {
+				msg = fmt.Sprintf("%s does not declare %s", filepath.Base(inc.Resolved), name)
+			}
+		} else if _, ok := a.cfg.Scalars[name]; ok {
+			continue
+		} else {
+			msg = fmt.Sprintf("unknown type %s", name)
+		}
+		diags = append(diags, Diagnostic{
+			Range:    ref.rng,
+			Severity: severityError,
+			Source:   "restgen",
+			Message:  msg,
+		})
+	}
+	return diags
+}
+
+// reference is a type reference in the document.
+type reference struct {
+	ref string
+	rng Range
+}
+
+// references returns every type reference of calls, arguments and fields.
+func (a *analysis) references() []reference {
+	var refs []reference
+	add := func(ref string, pos schema.Pos, after string) {
+		// The type follows the name and, for calls, the argument list
+		line := a.line(pos.Line - 1)
+		start := min(max(pos.Column-1, 0), len(line))
+		if i := strings.Index(line[start:], after); i >= 0 {
+			start += i
+		}
+		p := pos
+		p.Column = start + 1
+		refs = append(refs, reference{ref, nameRange(a.lines, p, ref)})
+	}
+
+	for _, c := range a.schema.Calls {
+		add(c.ReturnType, c.Pos, ")")
+		for _, arg := range c.Args {
+			add(arg.Type, arg.Pos, ":")
+		}
+	}
+	for _, t := range a.schema.Types {
+		for _, f := range t.Fields {
+			add(f.Type, f.Pos, ":")
+		}
+	}
+	for _, t := range a.schema.Inputs {
+		for _, f := range t.Fields {
+			add(f.Type, f.Pos, ":")
+		}
+	}
+	return refs
+}
+
+// Parser errors don't carry positions, so they are placed on the
+// declaration they name.
+var errorSites = []struct {
+	re      *regexp.Regexp
+	pattern string // %s is the quoted submatch
+}{
+	{regexp.MustCompile(`^parsing include ([^:]+):`), `"%s"`},
+	{regexp.MustCompile(`^parsing (?:type|input) (\w+):`), `\b(?:type|input)\s+%s\b`},
+	{regexp.MustCompile(`^(?:type|input|enum) (\w+):`), `\b(?:type|input|enum)\s+%s\b`},
+	{regexp.MustCompile(`^scalar (\w+):`), `\bscalar\s+%s\b`},
+	{regexp.MustCompile(`^parsing Calls block: parsing args for (\w+):`), `\b%s\s*\(`},
+	{regexp.MustCompile(`^(\w+): `), `\b%s\s*\(`},
+}
+
+func (a *analysis) parseError() Diagnostic {
+	msg := strings.TrimPrefix(a.err.Error(), "parsing "+a.path+": ")
+	d := Diagnostic{Severity: severityError, Source: "restgen", Message: msg}
+
+	// Syntax errors have an exact position
+	var se *sdlfmt.Error
+	if _, err := sdlfmt.Source([]byte(a.text)); errors.As(err, &se) {
+		line := a.line(se.Line - 1)
+		start := min(se.Column-1, len(line))
+		end := start
+		for end < len(line) && isNameByte(line[end]) {
+			end++
+		}
+		d.Range = lineRange(line, se.Line-1, start, max(end, min(start+1, len(line))))
+		d.Message = se.Msg
+		return d
+	}
+
+	for _, site := range errorSites {
+		m := site.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		re := regexp.MustCompile(fmt.Sprintf(site.pattern, regexp.QuoteMeta(m[1])))
+		if loc := re.FindStringIndex(a.text); loc != nil {
+			n := strings.Count(a.text[:loc[0]], "\n")
+			lineStart := strings.LastIndex(a.text[:loc[0]], "\n") + 1
+			d.Range = lineRange(a.lines[n], n, loc[0]-lineStart, loc[0]-lineStart+len(strings.TrimSpace(a.text[loc[0]:loc[1]])))
+			return d
+		}
+	}
+	return d
+}
+
+func (a *analysis) definition(p Position) *Location {
+	if path, ok := a.includeAt(p); ok {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(a.path), path)
+		}
+		return &Location{URI: pathToURI(path)}
+	}
+
+	word, start, _ := a.wordAt(p)
+	if word == "" || a.schema == nil {
+		return nil
+	}
+
+	// On the namespace of geo.Location, go to the @include
+	ns, _ := schema.ParseTypeRef(word)
+	if ns != "" && fromUTF16(a.line(p.Line), p.Character) < start+len(ns) {
+		if _, inc := a.include(a.schema, ns); inc != nil {
+			return &Location{URI: pathToURI(a.path), Range: nameRange(a.lines, inc.Pos, "@include")}
+		}
+		return nil
+	}
+
+	d, ok := a.lookup(word)
+	if !ok {
+		return nil
+	}
+	return &Location{URI: pathToURI(d.path), Range: nameRange(a.fileLines(d.path), d.pos, d.name)}
+}
+
+func (a *analysis) hover(p Position) *Hover {
+	word, start, end := a.wordAt(p)
+	if word == "" || a.schema == nil {
+		return nil
+	}
+	line := a.line(p.Line)
+	rng := lineRange(line, p.Line, start, end)
+	types := emitter.NewTypesEmitter(a.cfg)
+
+	var b strings.Builder
+
+	// A call, argument or field declared on this line
+	for _, c := range a.schema.Calls {
+		if c.Pos.Line-1 == p.Line && c.Name == word {
+			fmt.Fprintf(&b, "**%s** `%s`\n\nHandler `%s`, returns `%s`",
+				c.Method, routes.Join(a.schema.Base, c.Path), c.HandlerName(),
+				types.GoType(a.schema, c.ReturnType, c.ReturnRequired, c.ReturnIsList))
+			return &Hover{Contents: markdown(b.String()), Range: &rng}
+		}
+		for _, arg := range c.Args {
+			if arg.Pos.Line-1 == p.Line && arg.Name == word {
+				source := "query"
+				if c.PathParamSet()[arg.Name] {
+					source = "path"
+				} else if body := c.BodyArg(); body != nil && body.Name == arg.Name {
+					source = "body"
+				}
+				fmt.Fprintf(&b, "`%s: %s` (%s)\n\nGo: `%s`", arg.Name, typeString(arg.Type, arg.Required, arg.IsList),
+					source, types.GoType(a.schema, arg.Type, arg.Required, arg.IsList))
+				return &Hover{Contents: markdown(b.String()), Range: &rng}
+			}
+		}
+	}
+	for _, fields := range a.fieldLists() {
+		for _, f := range fields {
+			if f.Pos.Line-1 == p.Line && f.Name == word {
+				fmt.Fprintf(&b, "`%s: %s`\n\nGo: `%s`", f.Name, typeString(f.Type, f.Required, f.IsList),
+					types.GoType(a.schema, f.Type, f.Required, f.IsList))
+				return &Hover{Contents: markdown(b.String()), Range: &rng}
+			}
+		}
+	}
+
+	// A type reference
+	d, ok := a.lookup(word)
+	if !ok {
+		if sc, ok := a.cfg.Scalars[word]; ok {
+			fmt.Fprintf(&b, "scalar **%s**\n\nGo: `%s`", word, sc.GoType())
+			if sc.Import != "" {
+				fmt.Fprintf(&b, " (`%s`)", sc.Import)
+			}
+			return &Hover{Contents: markdown(b.String()), Range: &rng}
+		}
+		return nil
+	}
+
+	lines := a.fileLines(d.path)
+	if d.pos.Line >= 1 && d.pos.Line <= len(lines) {
+		last := min(blockEnd(lines, d.pos), d.pos.Line-1+30)
+		fmt.Fprintf(&b, "```graphql\n%s\n```\n\n", strings.Join(lines[d.pos.Line-1:last+1], "\n"))
+	}
+	if d.kind == "scalar" {
+		sc := a.cfg.Scalars[d.name]
+		fmt.Fprintf(&b, "Go: `%s`", sc.GoType())
+		if sc.Import != "" {
+			fmt.Fprintf(&b, " (`%s`)", sc.Import)
+		}
+	} else if gm := a.schema.GoModel(word); gm != "" {
+		fmt.Fprintf(&b, "Go: `%s` (bound with `@goModel`)", gm)
+	} else {
+		fmt.Fprintf(&b, "Go: `%s`", types.GoType(a.schema, word, true, false))
+		if models := firstNonEmpty(d.schema.Models, a.cfg.Models.Package); models != "" {
+			fmt.Fprintf(&b, " in `%s`", models)
+		}
+	}
+	fmt.Fprintf(&b, "\n\nDeclared in %s:%d", filepath.Base(d.path), d.pos.Line)
+	return &Hover{Contents: markdown(b.String()), Range: &rng}
+}
+
+func (a *analysis) fieldLists() [][]schema.Field {
+	var lists [][]schema.Field
+	for _, t := range a.schema.Types {
+		lists = append(lists, t.Fields)
+	}
+	for _, t := range a.schema.Inputs {
+		lists = append(lists, t.Fields)
+	}
+	return lists
+}
+
+func typeString(ref string, required, list bool) string {
+	if list {
+		ref = "[" + ref + "!]"
+	}
+	if required {
+		ref += "!"
+	}
+	return ref
+}
+
+func markdown(s string) markupContent {
+	return markupContent{Kind: "markdown", Value: s}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// directives are completed after @.
+var directives = []CompletionItem{
+	{Label: "base", Detail: "URL prefix of every call", InsertText: `base("/")`},
+	{Label: "models", Detail: "Go package for the types", InsertText: `models("")`},
+	{Label: "include", Detail: "use the types of another SDL file", InsertText: `include("")`},
+	{Label: "library", Detail: "include-only file: models, no routes"},
+	{Label: "get", Detail: "GET route", InsertText: `get("/")`},
+	{Label: "post", Detail: "POST route", InsertText: `post("/")`},
+	{Label: "put", Detail: "PUT route", InsertText: `put("/")`},
+	{Label: "patch", Detail: "PATCH route", InsertText: `patch("/")`},
+	{Label: "delete", Detail: "DELETE route", InsertText: `delete("/")`},
+	{Label: "goModel", Detail: "bind to an existing Go type", InsertText: `goModel("")`},
+	{Label: "goType", Detail: "Go type of a scalar", InsertText: `goType("")`},
+	{Label: "format", Detail: "OpenAPI format of a scalar", InsertText: `format("")`},
+}
+
+// completion offers directives after @, the declarations of an include
+// after its namespace, and otherwise every type name in scope. last is the
+// document's last parse that succeeded, used while the text is broken.
+func (a *analysis) completion(p Position, last *analysis) []CompletionItem {
+	line := a.line(p.Line)
+	col := fromUTF16(line, p.Character)
+	start := col
+	for start > 0 && (isNameByte(line[start-1]) || line[start-1] == '.' || line[start-1] == '@') {
+		start--
+	}
+	prefix := line[start:col]
+
+	if strings.HasPrefix(prefix, "@") {
+		items := make([]CompletionItem, len(directives))
+		for i, d := range directives {
+			d.Kind = kindKeyword
+			items[i] = d
+		}
+		return items
+	}
+
+	src := a
+	if src.schema == nil {
+		src = last
+	}
+	var items []CompletionItem
+	if strings.TrimSpace(line[:start]) == "" {
+		for _, kw := range []string{"type", "input", "enum", "scalar"} {
+			items = append(items, CompletionItem{Label: kw, Kind: kindKeyword})
+		}
+	}
+	if src == nil {
+		return items
+	}
+
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		is, _ := src.include(src.schema, prefix[:i])
+		if is == nil {
+			return nil
+		}
+		return declarationItems(is)
+	}
+
+	items = append(items, declarationItems(src.schema)...)
+	var scalars []string
+	for name := range src.cfg.Scalars {
+		scalars = append(scalars, name)
+	}
+	sort.Strings(scalars)
+	for _, name := range scalars {
+		if _, ok := declared(src.path, src.schema, name); ok {
+			continue
+		}
+		items = append(items, CompletionItem{Label: name, Kind: kindTypeParameter, Detail: "scalar → " + src.cfg.Scalars[name].GoType()})
+	}
+	for _, inc := range src.schema.Includes {
+		items = append(items, CompletionItem{Label: inc.Namespace, Kind: kindModule, Detail: "@include " + inc.Path})
+	}
+	return items
+}
+
+func declarationItems(s *schema.Schema) []CompletionItem {
+	var items []CompletionItem
+	for _, t := range s.Types {
+		items = append(items, CompletionItem{Label: t.Name, Kind: kindStruct, Detail: "type"})
+	}
+	for _, t := range s.Inputs {
+		items = append(items, CompletionItem{Label: t.Name, Kind: kindStruct, Detail: "input"})
+	}
+	for _, e := range s.Enums {
+		items = append(items, CompletionItem{Label: e.Name, Kind: kindEnum, Detail: "enum"})
+	}
+	for _, sc := range s.Scalars {
+		items = append(items, CompletionItem{Label: sc.Name, Kind: kindTypeParameter, Detail: "scalar → " + config.ScalarFromString(sc.GoType).GoType()})
+	}
+	return items
+}
+
+func (a *analysis) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	if a.schema == nil {
+		return symbols
+	}
+
+	block := func(name, detail string, kind int, pos schema.Pos, fields []schema.Field) DocumentSymbol {
+		end := blockEnd(a.lines, pos)
+		sym := DocumentSymbol{
+			Name:           name,
+			Detail:         detail,
+			Kind:           kind,
+			Range:          Range{Start: Position{pos.Line - 1, 0}, End: Position{end, toUTF16(a.line(end), len(a.line(end)))}},
+			SelectionRange: nameRange(a.lines, pos, name),
+		}
+		for _, f := range fields {
+			sym.Children = append(sym.Children, a.lineSymbol(f.Name, typeString(f.Type, f.Required, f.IsList), symbolField, f.Pos))
+		}
+		return sym
+	}
+
+	for _, sc := range a.schema.Scalars {
+		symbols = append(symbols, a.lineSymbol(sc.Name, "scalar", symbolTypeParameter, sc.Pos))
+	}
+	for _, c := range a.schema.Calls {
+		symbols = append(symbols, a.lineSymbol(c.Name, c.Method+" "+routes.Join(a.schema.Base, c.Path), symbolMethod, c.Pos))
+	}
+	for _, t := range a.schema.Types {
+		symbols = append(symbols, block(t.Name, "type", symbolStruct, t.Pos, t.Fields))
+	}
+	for _, t := range a.schema.Inputs {
+		symbols = append(symbols, block(t.Name, "input", symbolStruct, t.Pos, t.Fields))
+	}
+	for _, e := range a.schema.Enums {
+		symbols = append(symbols, block(e.Name, "enum", symbolEnum, e.Pos, nil))
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Range.Start.Line < symbols[j].Range.Start.Line
+	})
+	return symbols
+}
+
+func (a *analysis) lineSymbol(name, detail string, kind int, pos schema.Pos) DocumentSymbol {
+	line := a.line(pos.Line - 1)
+	return DocumentSymbol{
+		Name:           name,
+		Detail:         detail,
+		Kind:           kind,
+		Range:          lineRange(line, pos.Line-1, len(line)-len(strings.TrimLeft(line, " \t")), len(line)),
+		SelectionRange: nameRange(a.lines, pos, name),
+	}
+}
+
+func (a *analysis) formatting() ([]TextEdit, error) {
+	out, err := sdlfmt.Source([]byte(a.text))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(out, []byte(a.text)) {
+		return []TextEdit{}, nil
+	}
+	last := len(a.lines) - 1
+	return []TextEdit{{
+		Range:   Range{End: Position{last, toUTF16(a.lines[last], len(a.lines[last]))}},
+		NewText: string(out),
+	}}, nil
+}
@@ -0,0 +1,281 @@
+// Package lsp implements a language server for SDL files over stdio, on top
+// of the same parser the generator uses. It provides diagnostics,
+// go-to-definition, hover, completion, document symbols and formatting.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Server is a language server for one client connection. Requests are
+// handled one at a time, in the order they arrive.
+type Server struct {
+	in      *bufio.Reader
+	out     io.Writer
+	version string
+	logger  *log.Logger
+
+	docs     map[string]string // absolute path -> text of open documents
+	shutdown bool
+
+	// Schemas from the last successful parse of each open document, for
+	// completion while the current text doesn't parse.
+	lastGood map[string]*analysis
+}
+
+// NewServer returns a server reading requests from in and writing
+// responses to out. Logs go to stderr.
+func NewServer(in io.Reader, out io.Writer, version string) *Server {
+	return &Server{
+		in:       bufio.NewReader(in),
+		out:      out,
+		version:  version,
+		logger:   log.New(os.Stderr, "restgen lsp: ", 0),
+		docs:     make(map[string]string),
+		lastGood: make(map[string]*analysis),
+	}
+}
+
+// errExit is returned by handlers when the client sends exit.
+var errExit = errors.New("exit")
+
+// Run serves requests until the client exits or closes the connection. It
+// returns an error if the client exits without shutting down first.
+func (s *Server) Run() error {
+	for {
+		body, err := readMessage(s.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading message: %w", err)
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.logger.Printf("invalid message: %v", err)
+			continue
+		}
+
+		result, err := s.handle(req)
+		if err == errExit {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		if req.ID == nil {
+			// Notifications get no response
+			if err != nil {
+				s.logger.Printf("%s: %v", req.Method, err)
+			}
+			continue
+		}
+		if err := s.respond(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.setDocument(p.TextDocument.URI, &p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Full sync: the last change is the whole document
+		text := p.ContentChanges[len(p.ContentChanges)-1].Text
+		return nil, s.setDocument(p.TextDocument.URI, &text)
+	case "textDocument/didClose":
+		var p documentParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.setDocument(p.TextDocument.URI, nil)
+	case "textDocument/didSave":
+		return nil, nil
+
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.withDocument(p.TextDocument.URI, func(a *analysis) (any, error) {
+			return a.definition(p.Position), nil
+		})
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.withDocument(p.TextDocument.URI, func(a *analysis) (any, error) {
+			return a.hover(p.Position), nil
+		})
+	case "textDocument/completion":
+		var p textDocumentPositionParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.withDocument(p.TextDocument.URI, func(a *analysis) (any, error) {
+			return a.completion(p.Position, s.lastGood[a.path]), nil
+		})
+	case "textDocument/documentSymbol":
+		var p documentParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.withDocument(p.TextDocument.URI, func(a *analysis) (any, error) {
+			return a.symbols(), nil
+		})
+	case "textDocument/formatting":
+		var p documentParams
+		if err := unmarshalParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.withDocument(p.TextDocument.URI, func(a *analysis) (any, error) {
+			return a.formatting()
+		})
+	}
+
+	if req.ID == nil {
+		// Unknown notifications, such as $/cancelRequest, are ignored
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1, // full
+			},
+			"definitionProvider": true,
+			"hoverProvider":      true,
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{"@", "."},
+			},
+			"documentSymbolProvider":     true,
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]any{
+			"name":    "restgen",
+			"version": s.version,
+		},
+	}
+}
+
+// setDocument records the text of an open document, or forgets it when text
+// is nil, and republishes diagnostics for every open document, since they
+// may include it.
+func (s *Server) setDocument(uri string, text *string) error {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		delete(s.docs, path)
+		delete(s.lastGood, path)
+		// Clear its diagnostics
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{}})
+	}
+	s.docs[path] = *text
+
+	for path := range s.docs {
+		a := s.analyze(path)
+		if a.schema != nil {
+			s.lastGood[path] = a
+		}
+		if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         pathToURI(path),
+			Diagnostics: a.diagnostics(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withDocument analyzes the open document uri and calls fn with it.
+func (s *Server) withDocument(uri string, fn func(a *analysis) (any, error)) (any, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	if _, ok := s.docs[path]; !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: "document is not open: " + uri}
+	}
+	return fn(s.analyze(path))
+}
+
+// readFile reads path from the open documents, or from disk.
+func (s *Server) readFile(path string) ([]byte, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		if text, ok := s.docs[abs]; ok {
+			return []byte(text), nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
+func (s *Server) respond(id json.RawMessage, result any, err error) error {
+	resp := response{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		var re *responseError
+		if !errors.As(err, &re) {
+			re = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = re
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = data
+	}
+
+	return writeMessage(s.out, resp)
+}
+
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
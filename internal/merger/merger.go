@@ -34,8 +34,9 @@ func (m *Merger) Merge(generated, existingPath string) (*MergeResult, error) {
 	existing, err := os.ReadFile(existingPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// No existing file, use generated as-is
-			return &MergeResult{Content: generated}, nil
+			// No existing file, merge against nothing so the first write
+			// matches what later merges produce
+			return m.MergeContent(generated, "")
 		}
 		return nil, err
 	}
@@ -43,8 +44,8 @@ func (m *Merger) Merge(generated, existingPath string) (*MergeResult, error) {
 	return m.MergeContent(generated, string(existing))
 }
 
-// MergeContent merges generated content with existing content.
-// MergeContent merges generated content with existing content.
+// MergeContent merges generated content with existing content. Merging
+// against an empty existing file gives the content of a first write.
 func (m *Merger) MergeContent(generated, existing string) (*MergeResult, error) {
 	result := &MergeResult{}
 
@@ -91,13 +92,13 @@ func (m *Merger) MergeContent(generated, existing string) (*MergeResult, error)
 	belowMarker.WriteString("// HANDLER IMPLEMENTATIONS\n")
 	belowMarker.WriteString("// ============================================================================\n")
 
-	// Write methods in order from generated, using preserved implementations where available
+	// Write methods in order from generated, using preserved implementations where available,
+	// with a blank line between them as the template renders them
 	for _, m := range extractMethodsOrdered(generatedBelow) {
+		belowMarker.WriteString("\n\n")
 		if preserved, ok := preservedMethods[m.name]; ok {
-			belowMarker.WriteString("\n")
 			belowMarker.WriteString(preserved)
 		} else {
-			belowMarker.WriteString("\n")
 			belowMarker.WriteString(m.content)
 		}
 	}
@@ -5,114 +5,256 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/borderlesshq/restgen/internal/schema"
 )
 
 // Parser parses SDL files into schema IR.
+// It is safe for concurrent use; each file is parsed at most once.
 type Parser struct {
-	// baseDir is used to resolve relative @include paths
-	baseDir string
+	// readFile reads SDL files; os.ReadFile unless set by NewWithReader
+	readFile func(path string) ([]byte, error)
+
+	mu sync.Mutex
 	// cache prevents re-parsing the same file
-	cache map[string]*schema.Schema
+	cache map[string]*cacheEntry
+}
+
+// cacheEntry holds the result of parsing one file. done is closed once
+// schema and err are set, so concurrent callers can wait for it.
+type cacheEntry struct {
+	done   chan struct{}
+	schema *schema.Schema
+	err    error
+	// blockedOn is the include this file is currently waiting on, used to
+	// detect include cycles across goroutines.
+	blockedOn *cacheEntry
 }
 
-// New creates a new parser.
+// New creates a new parser that reads SDL files from disk.
 func New() *Parser {
+	return NewWithReader(os.ReadFile)
+}
+
+// NewWithReader creates a parser that reads SDL files with readFile.
+// Paths passed to readFile are absolute.
+func NewWithReader(readFile func(path string) ([]byte, error)) *Parser {
 	return &Parser{
-		cache: make(map[string]*schema.Schema),
+		readFile: readFile,
+		cache:    make(map[string]*cacheEntry),
 	}
 }
 
 // ParseFile parses a single SDL file.
 func (p *Parser) ParseFile(path string) (*schema.Schema, error) {
+	return p.parseFile(path, nil)
+}
+
+// parseFile parses path on behalf of parent, the file including it (nil
+// for a top-level file).
+func (p *Parser) parseFile(path string, parent *cacheEntry) (*schema.Schema, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("resolving path: %w", err)
 	}
 
+	p.mu.Lock()
+	entry, cached := p.cache[absPath]
+	if !cached {
+		entry = &cacheEntry{done: make(chan struct{})}
+		p.cache[absPath] = entry
+	}
+	if parent != nil {
+		// If the file we'd wait on is (transitively) waiting on us,
+		// the includes form a cycle.
+		for e := entry; e != nil; e = e.blockedOn {
+			if e == parent {
+				p.mu.Unlock()
+				return nil, fmt.Errorf("include cycle through %s", path)
+			}
+		}
+		parent.blockedOn = entry
+	}
+	p.mu.Unlock()
+
+	if parent != nil {
+		defer func() {
+			p.mu.Lock()
+			parent.blockedOn = nil
+			p.mu.Unlock()
+		}()
+	}
+
 	// Check cache
-	if cached, ok := p.cache[absPath]; ok {
-		return cached, nil
+	if cached {
+		<-entry.done
+		return entry.schema, entry.err
 	}
 
-	data, err := os.ReadFile(absPath)
+	entry.schema, entry.err = p.readAndParse(path, absPath, entry)
+	close(entry.done)
+
+	return entry.schema, entry.err
+}
+
+func (p *Parser) readAndParse(path, absPath string, entry *cacheEntry) (*schema.Schema, error) {
+	data, err := p.readFile(absPath)
 	if err != nil {
 		return nil, fmt.Errorf("reading file: %w", err)
 	}
 
-	// Set base dir for resolving includes
-	p.baseDir = filepath.Dir(absPath)
-
-	s, err := p.Parse(string(data))
+	// Includes are resolved relative to this file
+	s, err := p.parse(string(data), filepath.Dir(absPath), entry)
 	if err != nil {
 		return nil, fmt.Errorf("parsing %s: %w", path, err)
 	}
 
 	s.FileName = filepath.Base(path)
 
-	// Cache result
-	p.cache[absPath] = s
-
 	return s, nil
 }
 
+// IncludeClosure returns the absolute paths of every SDL file transitively
+// included by the file at path, in the order they are first reached.
+func (p *Parser) IncludeClosure(path string) ([]string, error) {
+	var closure []string
+	seen := make(map[string]bool)
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		s, err := p.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		for _, inc := range s.Includes {
+			if seen[inc.Resolved] {
+				continue
+			}
+			seen[inc.Resolved] = true
+			closure = append(closure, inc.Resolved)
+			if err := walk(inc.Resolved); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(path); err != nil {
+		return nil, err
+	}
+	return closure, nil
+}
+
+var (
+	// directiveRe matches a header directive at the start of a line,
+	// optionally inside a comment. Group 1 is its name, group 2 its
+	// argument.
+	directiveRe = regexp.MustCompile(`(?m)^[ \t]*(?:(?:#|//)[ \t]*)?@(base|models|include|library)\b(?:[ \t]*\([ \t]*"([^"]+)"[ \t]*\))?`)
+
+	// firstDeclRe matches the first declaration, which ends the header.
+	firstDeclRe = regexp.MustCompile(`(?m)^[ \t]*(?:type|input|enum|scalar)\b`)
+)
+
 // Parse parses SDL content into a Schema.
+// Relative @include paths are resolved against the working directory.
 func (p *Parser) Parse(content string) (*schema.Schema, error) {
+	return p.parse(content, "", nil)
+}
+
+// parse parses SDL content, resolving includes relative to baseDir.
+// entry is the cache entry of the file being parsed, if any.
+func (p *Parser) parse(content, baseDir string, entry *cacheEntry) (*schema.Schema, error) {
 	s := &schema.Schema{}
+	pos := newPositions(content)
 
-	// Parse directives from comments at top
+	// Parse header directives at the top, each starting its own line,
+	// bare or as the first thing in a comment:
 	//@base("/v1/contacts")
-	//@models("github.com/borderlesshq/api/models")
+	//# @models("github.com/borderlesshq/api/models")
 	//@include("path/to/other.sdl")
-	baseRe := regexp.MustCompile(`\s*@base\s*\(\s*"([^"]+)"\s*\)`)
-	modelsRe := regexp.MustCompile(`\s*@models\s*\(\s*"([^"]+)"\s*\)`)
-	includeRe := regexp.MustCompile(`\s*@include\s*\(\s*"([^"]+)"\s*\)`)
-
-	if m := baseRe.FindStringSubmatch(content); len(m) > 1 {
-		s.Base = m[1]
-	}
-	if m := modelsRe.FindStringSubmatch(content); len(m) > 1 {
-		s.Models = m[1]
+	//@library
+	header := content
+	if loc := firstDeclRe.FindStringIndex(content); loc != nil {
+		header = content[:loc[0]]
 	}
 
-	// Parse all includes
-	includeMatches := includeRe.FindAllStringSubmatch(content, -1)
-	for _, m := range includeMatches {
-		includePath := m[1]
+	for _, m := range directiveRe.FindAllStringSubmatchIndex(header, -1) {
+		name := content[m[2]:m[3]]
+		if name == "library" {
+			s.Library = true
+			s.Directives = append(s.Directives, schema.Directive{Name: name, Pos: pos.at(m[2] - 1)})
+			continue
+		}
+		if m[4] < 0 {
+			// Not a directive without its argument
+			continue
+		}
 
-		inc, err := p.parseInclude(includePath)
-		if err != nil {
-			return nil, fmt.Errorf("parsing include %s: %w", includePath, err)
+		arg := content[m[4]:m[5]]
+		d := schema.Directive{Name: name, Args: []string{arg}, Pos: pos.at(m[2] - 1)}
+		s.Directives = append(s.Directives, d)
+
+		switch name {
+		case "base":
+			// First one wins
+			if s.Base == "" {
+				s.Base = arg
+			}
+		case "models":
+			if s.Models == "" {
+				s.Models = arg
+			}
+		case "include":
+			inc, err := p.parseInclude(arg, baseDir, entry)
+			if err != nil {
+				return nil, fmt.Errorf("parsing include %s: %w", arg, err)
+			}
+			inc.Pos = d.Pos
+			s.Includes = append(s.Includes, *inc)
 		}
-		s.Includes = append(s.Includes, *inc)
 	}
 
+	scalars, err := parseScalars(content, pos)
+	if err != nil {
+		return nil, err
+	}
+	s.Scalars = scalars
+
 	// Parse type blocks using a proper brace-matching approach
-	blocks := extractBlocks(content)
+	blocks, err := extractBlocks(content)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, block := range blocks {
 		if block.name == "Calls" {
-			calls, err := p.parseCalls(block.body)
+			calls, err := p.parseCalls(block.body, block.bodyOffset, pos)
 			if err != nil {
 				return nil, fmt.Errorf("parsing Calls block: %w", err)
 			}
 			s.Calls = calls
 		} else if block.kind == "type" {
-			typeDef, err := p.parseTypeDef(block.name, block.body)
+			typeDef, err := p.parseTypeDef(block.name, block.body, block.bodyOffset, pos)
 			if err != nil {
 				return nil, fmt.Errorf("parsing type %s: %w", block.name, err)
 			}
+			typeDef.GoModel = block.goModel
+			typeDef.Pos = pos.at(block.offset)
 			s.Types = append(s.Types, *typeDef)
 		} else if block.kind == "input" {
-			inputDef, err := p.parseInputDef(block.name, block.body)
+			inputDef, err := p.parseInputDef(block.name, block.body, block.bodyOffset, pos)
 			if err != nil {
 				return nil, fmt.Errorf("parsing input %s: %w", block.name, err)
 			}
+			inputDef.GoModel = block.goModel
+			inputDef.Pos = pos.at(block.offset)
 			s.Inputs = append(s.Inputs, *inputDef)
 		} else if block.kind == "enum" {
 			enumDef := p.parseEnumDef(block.name, block.body)
+			enumDef.Pos = pos.at(block.offset)
 			s.Enums = append(s.Enums, *enumDef)
 		}
 	}
@@ -121,50 +263,148 @@ func (p *Parser) Parse(content string) (*schema.Schema, error) {
 }
 
 // parseInclude parses an included SDL file and extracts its metadata.
-func (p *Parser) parseInclude(includePath string) (*schema.Include, error) {
+func (p *Parser) parseInclude(includePath, baseDir string, entry *cacheEntry) (*schema.Include, error) {
 	// Resolve path relative to current SDL file
 	fullPath := includePath
-	if !filepath.IsAbs(includePath) && p.baseDir != "" {
-		fullPath = filepath.Join(p.baseDir, includePath)
+	if !filepath.IsAbs(includePath) && baseDir != "" {
+		fullPath = filepath.Join(baseDir, includePath)
+	}
+
+	absPath, err := filepath.Abs(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path: %w", err)
 	}
 
 	// Parse the included file (will use cache if already parsed)
-	includedSchema, err := p.ParseFile(fullPath)
+	includedSchema, err := p.parseFile(absPath, entry)
 	if err != nil {
 		return nil, err
 	}
 
 	// Derive namespace from filename
 	// e.g., "geo_models.sdl" -> "geo_models"
-	namespace := strings.TrimSuffix(filepath.Base(includePath), ".sdl")
+	namespace := schema.BaseName(includePath)
 	// Replace hyphens with underscores for valid Go identifiers
 	namespace = strings.ReplaceAll(namespace, "-", "_")
 
+	// Scalars are global: an include brings its own and its includes'
+	scalars := make([]schema.ScalarDef, 0, len(includedSchema.Scalars))
+	for _, inc := range includedSchema.Includes {
+		scalars = append(scalars, inc.Scalars...)
+	}
+	scalars = append(scalars, includedSchema.Scalars...)
+
+	goModels := make(map[string]string)
+	for _, t := range includedSchema.Types {
+		if t.GoModel != "" {
+			goModels[t.Name] = t.GoModel
+		}
+	}
+	for _, t := range includedSchema.Inputs {
+		if t.GoModel != "" {
+			goModels[t.Name] = t.GoModel
+		}
+	}
+
 	return &schema.Include{
 		Path:      includePath,
+		Resolved:  absPath,
 		Namespace: namespace,
 		Models:    includedSchema.Models,
+		Scalars:   scalars,
+		GoModels:  goModels,
 	}, nil
 }
 
+var (
+	scalarRe          = regexp.MustCompile(`(?m)^[ \t]*scalar[ \t]+(\w+)((?:[ \t]*@\w+[ \t]*\([ \t]*"[^"]*"[ \t]*\))*)`)
+	scalarDirectiveRe = regexp.MustCompile(`@(\w+)\s*\(\s*"([^"]*)"\s*\)`)
+)
+
+// parseScalars parses scalar declarations:
+//
+//	scalar Decimal @goType("github.com/shopspring/decimal.Decimal")
+//	scalar Email @goType("string") @format("email")
+func parseScalars(content string, pos *positions) ([]schema.ScalarDef, error) {
+	var scalars []schema.ScalarDef
+
+	for _, m := range scalarRe.FindAllStringSubmatchIndex(content, -1) {
+		def := schema.ScalarDef{
+			Name: content[m[2]:m[3]],
+			Pos:  pos.at(m[2]),
+		}
+
+		for _, d := range scalarDirectiveRe.FindAllStringSubmatch(content[m[4]:m[5]], -1) {
+			switch d[1] {
+			case "goType":
+				def.GoType = d[2]
+			case "format":
+				def.Format = d[2]
+			default:
+				return nil, fmt.Errorf("scalar %s: unknown directive @%s", def.Name, d[1])
+			}
+		}
+
+		if def.GoType == "" {
+			return nil, fmt.Errorf("scalar %s: missing @goType", def.Name)
+		}
+
+		scalars = append(scalars, def)
+	}
+
+	return scalars, nil
+}
+
 type block struct {
-	kind string
-	name string
-	body string
+	kind       string
+	name       string
+	goModel    string // from @goModel("..."); blocks bound this way may have no body
+	body       string
+	offset     int // offset of the block keyword in the file
+	bodyOffset int // offset of body in the file
 }
 
+var (
+	blockRe          = regexp.MustCompile(`(type|input|enum)\s+(\w+)((?:\s*@\w+\s*\(\s*"[^"]*"\s*\))*)\s*`)
+	blockDirectiveRe = regexp.MustCompile(`@(\w+)\s*\(\s*"([^"]*)"\s*\)`)
+)
+
 // extractBlocks extracts type/input/enum blocks handling nested braces.
-func extractBlocks(content string) []block {
+// Types and inputs bound to Go types with @goModel may omit the body:
+//
+//	type Money @goModel("github.com/acme/money.Amount")
+func extractBlocks(content string) ([]block, error) {
 	var blocks []block
 
-	// Find "type Name {" or "input Name {" or "enum Name {"
-	re := regexp.MustCompile(`(type|input|enum)\s+(\w+)\s*\{`)
-	matches := re.FindAllStringSubmatchIndex(content, -1)
+	// Find "type Name {" or "input Name {" or "enum Name {", optionally
+	// with directives before the brace
+	matches := blockRe.FindAllStringSubmatchIndex(content, -1)
 
 	for _, match := range matches {
 		kind := content[match[2]:match[3]]
 		name := content[match[4]:match[5]]
-		braceStart := match[1] - 1 // position of opening {
+		directives := content[match[6]:match[7]]
+		hasBody := match[1] < len(content) && content[match[1]] == '{'
+
+		if directives == "" && !hasBody {
+			// Not a definition, e.g. "type" in a comment
+			continue
+		}
+
+		b := block{kind: kind, name: name, offset: match[0]}
+		for _, d := range blockDirectiveRe.FindAllStringSubmatch(directives, -1) {
+			if d[1] != "goModel" || kind == "enum" {
+				return nil, fmt.Errorf("%s %s: unknown directive @%s", kind, name, d[1])
+			}
+			b.goModel = d[2]
+		}
+
+		if !hasBody {
+			blocks = append(blocks, b)
+			continue
+		}
+
+		braceStart := match[1] // position of opening {
 
 		// Find matching closing brace
 		depth := 1
@@ -182,33 +422,32 @@ func extractBlocks(content string) []block {
 			}
 		}
 
-		blocks = append(blocks, block{
-			kind: kind,
-			name: name,
-			body: content[bodyStart:bodyEnd],
-		})
+		b.body = content[bodyStart:bodyEnd]
+		b.bodyOffset = bodyStart
+		blocks = append(blocks, b)
 	}
 
-	return blocks
+	return blocks, nil
 }
 
 // parseCalls parses the Calls block content.
-func (p *Parser) parseCalls(body string) ([]schema.Call, error) {
+func (p *Parser) parseCalls(body string, bodyOffset int, pos *positions) ([]schema.Call, error) {
 	var calls []schema.Call
 
 	// Match: createContact(input: CreateContactInput!): Contact! @post("/")
 	// Also handles namespaced types: geo.Location, [geo.Location!]!
 	callRe := regexp.MustCompile(`(\w+)\s*\(([^)]*)\)\s*:\s*(\[?[\w.]+!?\]?!?)\s*@(get|post|put|patch|delete)\s*\(\s*"([^"]+)"\s*\)`)
 
-	matches := callRe.FindAllStringSubmatch(body, -1)
-	for _, m := range matches {
+	matches := callRe.FindAllStringSubmatchIndex(body, -1)
+	for _, idx := range matches {
+		m := submatches(body, idx)
 		name := m[1]
 		argsStr := m[2]
 		returnTypeRaw := m[3]
 		method := strings.ToUpper(m[4])
 		path := m[5]
 
-		args, err := p.parseArgs(argsStr)
+		args, err := p.parseArgs(argsStr, bodyOffset+idx[4], pos)
 		if err != nil {
 			return nil, fmt.Errorf("parsing args for %s: %w", name, err)
 		}
@@ -231,16 +470,19 @@ func (p *Parser) parseCalls(body string) ([]schema.Call, error) {
 		}
 
 		// Remove inner ! for list items like [Type!]
+		returnItemRequired := returnIsList && strings.HasSuffix(returnType, "!")
 		returnType = strings.TrimSuffix(returnType, "!")
 
 		call := schema.Call{
-			Name:           name,
-			Method:         method,
-			Path:           path,
-			Args:           args,
-			ReturnType:     returnType,
-			ReturnRequired: returnRequired,
-			ReturnIsList:   returnIsList,
+			Name:               name,
+			Method:             method,
+			Path:               path,
+			Args:               args,
+			ReturnType:         returnType,
+			ReturnRequired:     returnRequired,
+			ReturnIsList:       returnIsList,
+			ReturnItemRequired: returnItemRequired,
+			Pos:                pos.at(bodyOffset + idx[0]),
 		}
 
 		// Validate the call
@@ -256,7 +498,7 @@ func (p *Parser) parseCalls(body string) ([]schema.Call, error) {
 
 // parseArgs parses function arguments like "id: ID!, input: CreateContactInput"
 // Also handles namespaced types: geo.Location, [geo.Location!]!
-func (p *Parser) parseArgs(argsStr string) ([]schema.Arg, error) {
+func (p *Parser) parseArgs(argsStr string, argsOffset int, pos *positions) ([]schema.Arg, error) {
 	if strings.TrimSpace(argsStr) == "" {
 		return nil, nil
 	}
@@ -266,11 +508,12 @@ func (p *Parser) parseArgs(argsStr string) ([]schema.Arg, error) {
 	// Split by comma, handling nested brackets
 	parts := splitArgs(argsStr)
 
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
+	for _, ap := range parts {
+		part := strings.TrimSpace(ap.text)
 		if part == "" {
 			continue
 		}
+		partOffset := argsOffset + ap.offset + (len(ap.text) - len(strings.TrimLeft(ap.text, " \t\r\n")))
 
 		// Parse: name: Type! or name: [Type!]! or name: geo.Type!
 		colonIdx := strings.Index(part, ":")
@@ -281,7 +524,7 @@ func (p *Parser) parseArgs(argsStr string) ([]schema.Arg, error) {
 		name := strings.TrimSpace(part[:colonIdx])
 		typeStr := strings.TrimSpace(part[colonIdx+1:])
 
-		arg := schema.Arg{Name: name}
+		arg := schema.Arg{Name: name, Pos: pos.at(partOffset)}
 
 		// Check for outer required: [Type!]! or Type!
 		if strings.HasSuffix(typeStr, "!") {
@@ -296,6 +539,7 @@ func (p *Parser) parseArgs(argsStr string) ([]schema.Arg, error) {
 		}
 
 		// Remove inner ! for list items like [Type!]
+		arg.ItemRequired = arg.IsList && strings.HasSuffix(typeStr, "!")
 		typeStr = strings.TrimSuffix(typeStr, "!")
 
 		arg.Type = typeStr
@@ -306,8 +550,8 @@ func (p *Parser) parseArgs(argsStr string) ([]schema.Arg, error) {
 }
 
 // parseTypeDef parses a type block into a TypeDef.
-func (p *Parser) parseTypeDef(name, body string) (*schema.TypeDef, error) {
-	fields, err := p.parseFields(body)
+func (p *Parser) parseTypeDef(name, body string, bodyOffset int, pos *positions) (*schema.TypeDef, error) {
+	fields, err := p.parseFields(body, bodyOffset, pos)
 	if err != nil {
 		return nil, err
 	}
@@ -315,8 +559,8 @@ func (p *Parser) parseTypeDef(name, body string) (*schema.TypeDef, error) {
 }
 
 // parseInputDef parses an input block into an InputDef.
-func (p *Parser) parseInputDef(name, body string) (*schema.InputDef, error) {
-	fields, err := p.parseFields(body)
+func (p *Parser) parseInputDef(name, body string, bodyOffset int, pos *positions) (*schema.InputDef, error) {
+	fields, err := p.parseFields(body, bodyOffset, pos)
 	if err != nil {
 		return nil, err
 	}
@@ -342,12 +586,16 @@ func (p *Parser) parseEnumDef(name, body string) *schema.EnumDef {
 }
 
 // parseFields parses field definitions like "id: ID!" or "items: [Contact!]!"
-func (p *Parser) parseFields(body string) ([]schema.Field, error) {
+func (p *Parser) parseFields(body string, bodyOffset int, pos *positions) ([]schema.Field, error) {
 	var fields []schema.Field
 
+	lineOffset := bodyOffset
 	lines := strings.Split(body, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for _, rawLine := range lines {
+		fieldOffset := lineOffset + len(rawLine) - len(strings.TrimLeft(rawLine, " \t\r"))
+		lineOffset += len(rawLine) + 1
+
+		line := strings.TrimSpace(rawLine)
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
 			continue
@@ -361,7 +609,7 @@ func (p *Parser) parseFields(body string) ([]schema.Field, error) {
 		name := strings.TrimSpace(line[:colonIdx])
 		typeStr := strings.TrimSpace(line[colonIdx+1:])
 
-		field := schema.Field{Name: name}
+		field := schema.Field{Name: name, Pos: pos.at(fieldOffset)}
 
 		// Check for outer required: [Type!]! or Type!
 		if strings.HasSuffix(typeStr, "!") {
@@ -376,6 +624,7 @@ func (p *Parser) parseFields(body string) ([]schema.Field, error) {
 		}
 
 		// Remove inner ! for list items like [Contact!]
+		field.ItemRequired = field.IsList && strings.HasSuffix(typeStr, "!")
 		typeStr = strings.TrimSuffix(typeStr, "!")
 
 		field.Type = typeStr
@@ -385,13 +634,20 @@ func (p *Parser) parseFields(body string) ([]schema.Field, error) {
 	return fields, nil
 }
 
+// argPart is one comma-separated argument and its offset in the args string.
+type argPart struct {
+	text   string
+	offset int
+}
+
 // splitArgs splits comma-separated args, respecting nested brackets.
-func splitArgs(s string) []string {
-	var parts []string
+func splitArgs(s string) []argPart {
+	var parts []argPart
 	var current strings.Builder
+	start := 0
 	depth := 0
 
-	for _, ch := range s {
+	for i, ch := range s {
 		switch ch {
 		case '[':
 			depth++
@@ -401,8 +657,9 @@ func splitArgs(s string) []string {
 			current.WriteRune(ch)
 		case ',':
 			if depth == 0 {
-				parts = append(parts, current.String())
+				parts = append(parts, argPart{text: current.String(), offset: start})
 				current.Reset()
+				start = i + 1
 			} else {
 				current.WriteRune(ch)
 			}
@@ -412,8 +669,43 @@ func splitArgs(s string) []string {
 	}
 
 	if current.Len() > 0 {
-		parts = append(parts, current.String())
+		parts = append(parts, argPart{text: current.String(), offset: start})
 	}
 
 	return parts
 }
+
+// submatches returns the submatch strings for a FindAllStringSubmatchIndex
+// result, with "" for groups that didn't match.
+func submatches(s string, idx []int) []string {
+	m := make([]string, len(idx)/2)
+	for i := range m {
+		if idx[2*i] >= 0 {
+			m[i] = s[idx[2*i]:idx[2*i+1]]
+		}
+	}
+	return m
+}
+
+// positions converts byte offsets in an SDL file to line/column positions.
+type positions struct {
+	lineStarts []int
+}
+
+func newPositions(content string) *positions {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &positions{lineStarts: starts}
+}
+
+// at returns the 1-based position of offset.
+func (p *positions) at(offset int) schema.Pos {
+	line := sort.Search(len(p.lineStarts), func(i int) bool {
+		return p.lineStarts[i] > offset
+	})
+	return schema.Pos{Line: line, Column: offset - p.lineStarts[line-1] + 1}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseFileIncludeCyclesConcurrently(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string][]string // file -> files it includes
+		wantErr bool
+	}{
+		{
+			name:    "self",
+			files:   map[string][]string{"a.sdl": {"a.sdl"}},
+			wantErr: true,
+		},
+		{
+			name:    "two files",
+			files:   map[string][]string{"a.sdl": {"b.sdl"}, "b.sdl": {"a.sdl"}},
+			wantErr: true,
+		},
+		{
+			name:    "three files",
+			files:   map[string][]string{"a.sdl": {"b.sdl"}, "b.sdl": {"c.sdl"}, "c.sdl": {"a.sdl"}},
+			wantErr: true,
+		},
+		{
+			name: "diamond",
+			files: map[string][]string{
+				"a.sdl": {"b.sdl", "c.sdl"},
+				"b.sdl": {"d.sdl"},
+				"c.sdl": {"d.sdl"},
+				"d.sdl": nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var names []string
+			for name, includes := range tt.files {
+				names = append(names, name)
+				var src strings.Builder
+				for _, inc := range includes {
+					src.WriteString(`@include("` + inc + `")` + "\n")
+				}
+				src.WriteString("\ntype " + strings.ToUpper(strings.TrimSuffix(name, ".sdl")) + " {\n    id: ID!\n}\n")
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(src.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Goroutines start on different files of the cycle, so each
+			// ends up waiting on another
+			for run := 0; run < 50; run++ {
+				p := New()
+				errs := make([]error, len(names))
+				var wg sync.WaitGroup
+				for i, name := range names {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						_, errs[i] = p.ParseFile(filepath.Join(dir, name))
+					}()
+				}
+
+				done := make(chan struct{})
+				go func() {
+					wg.Wait()
+					close(done)
+				}()
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("run %d: parsing deadlocked", run)
+				}
+
+				for i, err := range errs {
+					if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "include cycle")) {
+						t.Fatalf("run %d: ParseFile(%s) error = %v, want an include cycle", run, names[i], err)
+					}
+					if !tt.wantErr && err != nil {
+						t.Fatalf("run %d: ParseFile(%s) error = %v", run, names[i], err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseListItemRequired(t *testing.T) {
+	s, err := New().Parse(`
+type Calls {
+    listThings(tags: [String!], ids: [ID]!): [Thing!]! @get("/")
+    maybeThings(): [Thing]! @get("/maybe")
+}
+
+type Thing {
+    tags: [String!]!
+    notes: [String]
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := s.Calls
+	if !calls[0].ReturnItemRequired || calls[1].ReturnItemRequired {
+		t.Errorf("ReturnItemRequired = %v, %v; want true, false", calls[0].ReturnItemRequired, calls[1].ReturnItemRequired)
+	}
+	args := calls[0].Args
+	if !args[0].ItemRequired || args[1].ItemRequired {
+		t.Errorf("arg ItemRequired = %v, %v; want true, false", args[0].ItemRequired, args[1].ItemRequired)
+	}
+	fields := s.Types[0].Fields
+	if !fields[0].ItemRequired || fields[1].ItemRequired {
+		t.Errorf("field ItemRequired = %v, %v; want true, false", fields[0].ItemRequired, fields[1].ItemRequired)
+	}
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/ir"
+)
+
+// ExecutablePrefix is prepended to plugin names that aren't found on PATH
+// as given, so `plugins: [openapi]` runs restgen-gen-openapi.
+const ExecutablePrefix = "restgen-gen-"
+
+// Request is written to the plugin's stdin as JSON.
+type Request struct {
+	Version int          `json:"version"` // ir.Version
+	IR      *ir.Document `json:"ir"`
+}
+
+// Response is read from the plugin's stdout as JSON.
+type Response struct {
+	Files []File `json:"files"`
+	Error string `json:"error,omitempty"` // set to fail generation with this message
+}
+
+// File is a file produced by a plugin. Path is relative to the directory
+// restgen runs in.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Run executes the plugin called name with req on stdin and returns the
+// files it produced. The plugin's stderr is passed through.
+func Run(ctx context.Context, name string, req *Request) ([]File, error) {
+	path, err := lookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running %s: %w", path, err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("decoding response from %s: %w", path, err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	for _, f := range resp.Files {
+		if err := checkPath(f.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Files, nil
+}
+
+// lookPath finds the plugin executable. Names containing a path separator
+// are used as-is; bare names are looked up on PATH, first verbatim and then
+// with ExecutablePrefix.
+func lookPath(name string) (string, error) {
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return exec.LookPath(name)
+	}
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	path, err := exec.LookPath(ExecutablePrefix + name)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s not found on PATH (tried %s and %s%s)", name, name, ExecutablePrefix, name)
+	}
+	return path, nil
+}
+
+// checkPath rejects output paths that would escape the working directory.
+func checkPath(path string) error {
+	if path == "" {
+		return errors.New("plugin returned a file without a path")
+	}
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("plugin returned absolute path %s", path)
+	}
+	clean := filepath.Clean(path)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("plugin returned path %s outside the working directory", path)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package routes builds the table of every endpoint the schemas define, as
+// mounted under their @base, and finds routes that conflict or shadow each
+// other.
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/ir"
+)
+
+// Route is a single endpoint.
+type Route struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`    // @base + call path
+	Handler string `json:"handler"` // e.g., "ContactsHandler.GetContact"
+	Call    string `json:"call"`
+	Schema  string `json:"schema"`
+	Args    []Arg  `json:"args,omitempty"`
+	Returns string `json:"returns"`
+	Pos     ir.Pos `json:"pos"`
+}
+
+// Arg is a call argument and where it is bound from.
+type Arg struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Source string `json:"source"` // "path", "query" or "body"
+}
+
+// String describes r for messages: "GET /v1/contacts/{id} (contacts.sdl:7 getContact)".
+func (r Route) String() string {
+	return fmt.Sprintf("%s %s (%s:%d %s)", r.Method, r.Path, r.Schema, r.Pos.Line, r.Call)
+}
+
+// FromIR returns the routes of every root schema in the order they are
+// declared. Included and @library schemas generate no routes.
+func FromIR(schemas []ir.Schema) []Route {
+	var routes []Route
+	for _, s := range schemas {
+		if !s.Root || s.Library {
+			continue
+		}
+		for _, c := range s.Calls {
+			r := Route{
+				Method:  c.Method,
+				Path:    Join(s.Base, c.Path),
+				Handler: s.Handler + "." + c.Handler,
+				Call:    c.Name,
+				Schema:  s.File,
+				Returns: c.Returns.String(),
+				Pos:     c.Pos,
+			}
+			for _, a := range c.Args {
+				r.Args = append(r.Args, Arg{Name: a.Name, Type: a.Type.String(), Source: a.Source})
+			}
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// Join returns the path a call is served at when its handler is mounted at
+// base.
+func Join(base, path string) string {
+	full := strings.TrimSuffix(base, "/") + path
+	if len(full) > 1 {
+		full = strings.TrimSuffix(full, "/")
+	}
+	if full == "" {
+		return "/"
+	}
+	return full
+}
+
+// methodOrder sorts methods in the order they are usually listed.
+var methodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4}
+
+// Sort orders routes by path, then method.
+func Sort(routes []Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return methodOrder[routes[i].Method] < methodOrder[routes[j].Method]
+	})
+}
+
+// Conflict kinds
+const (
+	// Duplicate routes have the same method and path, up to the names of
+	// their parameters. Only one of them can be served.
+	Duplicate = "duplicate"
+
+	// Ambiguous routes match some of the same requests without either being
+	// more specific, such as /{a}/x and /x/{b}. net/http refuses to
+	// register them; chi serves them, trying static segments first.
+	Ambiguous = "ambiguous"
+
+	// A shadowed route is matched entirely by a more general one, such as
+	// /locations/search by /{id}/search or /locations/{id}. chi and
+	// net/http prefer the static segment, but routers that match in
+	// declaration order would never reach it.
+	Shadowed = "shadowed"
+)
+
+// Conflict is a pair of routes that match the same requests.
+type Conflict struct {
+	Kind    string  `json:"kind"`
+	Error   bool    `json:"error"` // Duplicate, and Ambiguous with net/http, break routing
+	Routes  []Route `json:"routes"`
+	Message string  `json:"message"`
+}
+
+// Conflicts finds every pair of routes with the same method that match the
+// same requests, as served by router (config.RouterChi or
+// config.RouterStdlib).
+func Conflicts(routes []Route, router string) []Conflict {
+	var out []Conflict
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if a.Method != b.Method {
+				continue
+			}
+			sa, sb := segments(a.Path), segments(b.Path)
+			if len(sa) != len(sb) {
+				continue
+			}
+
+			aCovers, bCovers := covers(sa, sb), covers(sb, sa)
+			switch {
+			case aCovers && bCovers:
+				out = append(out, Conflict{
+					Kind: Duplicate, Error: true, Routes: []Route{a, b},
+					Message: fmt.Sprintf("%s and %s are the same route", a, b),
+				})
+			case aCovers:
+				out = append(out, shadowed(a, b))
+			case bCovers:
+				out = append(out, shadowed(b, a))
+			case overlap(sa, sb):
+				out = append(out, Conflict{
+					Kind: Ambiguous, Error: router == config.RouterStdlib, Routes: []Route{a, b},
+					Message: fmt.Sprintf("%s and %s both match some requests and neither is more specific", a, b),
+				})
+			}
+		}
+	}
+	return out
+}
+
+func shadowed(general, specific Route) Conflict {
+	msg := fmt.Sprintf("%s also matches %s", general, specific)
+	if general.Schema == specific.Schema && general.Pos.Line < specific.Pos.Line {
+		msg += ", which is declared after it"
+	}
+	return Conflict{Kind: Shadowed, Routes: []Route{general, specific}, Message: msg}
+}
+
+func segments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isParam(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+// covers reports whether every request matched by b is matched by a.
+func covers(a, b []string) bool {
+	for i := range a {
+		if !isParam(a[i]) && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// overlap reports whether some request is matched by both a and b.
+func overlap(a, b []string) bool {
+	for i := range a {
+		if !isParam(a[i]) && !isParam(b[i]) && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasErrors reports whether any conflict breaks routing.
+func HasErrors(conflicts []Conflict) bool {
+	for _, c := range conflicts {
+		if c.Error {
+			return true
+		}
+	}
+	return false
+}
@@ -4,66 +4,195 @@ import "strings"
 
 // Schema represents the intermediate representation of a parsed SDL file.
 type Schema struct {
-	FileName string    // source file name (e.g., "contacts.sdl")
-	Base     string    // base path (e.g., "/v1/contacts")
-	Models   string    // models package (e.g., "github.com/borderlesshq/api/models")
-	Includes []Include // included SDL files
-	Calls    []Call
-	Types    []TypeDef
-	Inputs   []InputDef
-	Enums    []EnumDef
+	FileName   string      // source file name (e.g., "contacts.sdl")
+	Base       string      // base path (e.g., "/v1/contacts")
+	Models     string      // models package (e.g., "github.com/borderlesshq/api/models")
+	Library    bool        // marked @library: include-only, generates models but no routes
+	Directives []Directive // header directives in source order
+	Includes   []Include   // included SDL files
+	Scalars    []ScalarDef // scalar declarations
+	Calls      []Call
+	Types      []TypeDef
+	Inputs     []InputDef
+	Enums      []EnumDef
+}
+
+// Extensions are the file extensions recognised as SDL.
+var Extensions = []string{".sdl", ".graphql", ".gql"}
+
+// BaseName returns the name of an SDL file without its directory and SDL
+// extension: "schemas/contacts.graphql" -> "contacts".
+func BaseName(path string) string {
+	base := path
+	if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+		base = base[i+1:]
+	}
+	for _, ext := range Extensions {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
+}
+
+// Pos is a 1-based line and column in an SDL file.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+// Directive is a header directive such as @base("/v1/contacts").
+type Directive struct {
+	Name string   // directive name without @ (e.g., "base")
+	Args []string // string arguments
+	Pos  Pos
 }
 
 // Include represents an imported SDL file.
 type Include struct {
-	Path      string // relative path to SDL file
-	Namespace string // derived namespace (filename without .sdl)
-	Models    string // the @models package from included SDL
+	Path      string            // relative path to SDL file
+	Resolved  string            // absolute path of the included SDL file
+	Namespace string            // derived namespace (filename without extension)
+	Models    string            // the @models package from included SDL
+	Scalars   []ScalarDef       // scalars declared by the included SDL and its includes
+	GoModels  map[string]string // type/input name -> @goModel Go type in the included SDL
+	Pos       Pos
+}
+
+// GoModel returns the Go type a type reference is bound to with @goModel,
+// or "" if it is generated. Namespaced references are looked up in the
+// matching include.
+func (s *Schema) GoModel(typeRef string) string {
+	ns, name := ParseTypeRef(typeRef)
+	if ns != "" {
+		for _, inc := range s.Includes {
+			if inc.Namespace == ns {
+				return inc.GoModels[name]
+			}
+		}
+		return ""
+	}
+
+	for _, t := range s.Types {
+		if t.Name == name {
+			return t.GoModel
+		}
+	}
+	for _, t := range s.Inputs {
+		if t.Name == name {
+			return t.GoModel
+		}
+	}
+	return ""
+}
+
+// HandlerName returns the name of the handler type generated for s,
+// without its Handler suffix: from the file name (business_locations.sdl ->
+// BusinessLocations), or else the last segment of @base.
+func (s *Schema) HandlerName() string {
+	if s.FileName != "" {
+		if name := BaseName(s.FileName); name != "" {
+			return PascalCase(name)
+		}
+	}
+	if s.Base != "" {
+		parts := strings.Split(strings.Trim(s.Base, "/"), "/")
+		return PascalCase(parts[len(parts)-1])
+	}
+	return "Handler"
+}
+
+// PascalCase converts snake_case or kebab-case to PascalCase.
+func PascalCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// HasModels reports whether s has any types, inputs or enums to generate.
+func (s *Schema) HasModels() bool {
+	if len(s.Enums) > 0 {
+		return true
+	}
+	for _, t := range s.Types {
+		if t.GoModel == "" {
+			return true
+		}
+	}
+	for _, t := range s.Inputs {
+		if t.GoModel == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ScalarDef is a scalar declared in SDL:
+//
+//	scalar Decimal @goType("github.com/shopspring/decimal.Decimal")
+type ScalarDef struct {
+	Name   string
+	GoType string // Go type, qualified with its full import path if not builtin
+	Format string // OpenAPI format (e.g., "email"), from @format
+	Pos    Pos
 }
 
 // Call represents a single API endpoint definition.
 type Call struct {
-	Name           string // function name (e.g., "createContact")
-	Method         string // HTTP method (e.g., "POST", "GET")
-	Path           string // route path (e.g., "/", "/{id}")
-	Args           []Arg
-	ReturnType     string // return type (e.g., "Contact", "external.Location")
-	ReturnRequired bool   // true if return type is non-nullable (has !)
-	ReturnIsList   bool   // true if return type is a list [Type]
+	Name               string // function name (e.g., "createContact")
+	Method             string // HTTP method (e.g., "POST", "GET")
+	Path               string // route path (e.g., "/", "/{id}")
+	Args               []Arg
+	ReturnType         string // return type (e.g., "Contact", "external.Location")
+	ReturnRequired     bool   // true if return type is non-nullable (has !)
+	ReturnIsList       bool   // true if return type is a list [Type]
+	ReturnItemRequired bool   // true if list items are non-nullable: [Type!]
+	Pos                Pos
 }
 
 // Arg represents a function argument.
 type Arg struct {
-	Name     string // argument name
-	Type     string // type name (e.g., "String", "ID", "CreateContactInput", "external.Location")
-	Required bool   // true if non-nullable (has !)
-	IsList   bool   // true if array type [Type]
+	Name         string // argument name
+	Type         string // type name (e.g., "String", "ID", "CreateContactInput", "external.Location")
+	Required     bool   // true if non-nullable (has !)
+	IsList       bool   // true if array type [Type]
+	ItemRequired bool   // true if list items are non-nullable: [Type!]
+	Pos          Pos
 }
 
 // TypeDef represents a type definition (output types).
 type TypeDef struct {
-	Name   string
-	Fields []Field
+	Name    string
+	Fields  []Field
+	GoModel string // existing Go type from @goModel("pkg/path.Type"); not generated
+	Pos     Pos
 }
 
 // InputDef represents an input definition (input types for mutations).
 type InputDef struct {
-	Name   string
-	Fields []Field
+	Name    string
+	Fields  []Field
+	GoModel string // existing Go type from @goModel("pkg/path.Type"); not generated
+	Pos     Pos
 }
 
 // EnumDef represents an enum definition.
 type EnumDef struct {
 	Name   string
 	Values []string
+	Pos    Pos
 }
 
 // Field represents a field in a type or input.
 type Field struct {
-	Name     string
-	Type     string // can be "TypeName" or "namespace.TypeName"
-	Required bool
-	IsList   bool
+	Name         string
+	Type         string // can be "TypeName" or "namespace.TypeName"
+	Required     bool
+	IsList       bool
+	ItemRequired bool // true if list items are non-nullable: [Type!]
+	Pos          Pos
 }
 
 // HandlerName returns the exported Go function name for this call.
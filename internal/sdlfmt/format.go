@@ -0,0 +1,583 @@
+// Package sdlfmt formats SDL in restgen's canonical layout, keeping
+// comments and descriptions attached to the nodes they precede.
+//
+// The canonical layout is:
+//   - comments at the top of the file first
+//   - header directives next, one per line, in the order @base, @models,
+//     @library, @include, followed by a blank line
+//   - one blank line between top-level declarations; consecutive scalar
+//     declarations stay together
+//   - four-space indentation inside blocks, at most one blank line between
+//     members and none at the start or end of a block; the body of a
+//     block string description keeps its indentation relative to the
+//     description
+//   - `name: Type` fields and `a: T, b: U` argument lists
+//   - the directives of the calls in a Calls block aligned in a column,
+//     within each run of calls not broken by a blank line
+package sdlfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a syntax error at a 1-based line and column.
+type Error struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Source formats src. It returns an *Error if src can't be parsed.
+func Source(src []byte) ([]byte, error) {
+	toks, err := scan(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	f, err := p.file()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(f.print()), nil
+}
+
+// node is the part of every formatted construct that carries comments.
+type node struct {
+	// comments are the comment and description lines before the node. An
+	// empty string is a blank line between groups of them.
+	comments []string
+
+	// blankBefore is set when a blank line preceded the node (or its
+	// comments) in the source.
+	blankBefore bool
+
+	trailing string // comment on the same line as the end of the node
+}
+
+type item struct {
+	node
+	kind    string // "directive", "scalar" or "block"
+	name    string // directive name, or block name
+	header  string // everything but the body
+	hasBody bool
+	calls   bool
+
+	openTrailing string // comment after the opening brace
+	members      []member
+	endComments  []string // comments before the closing brace
+}
+
+type member struct {
+	node
+	text string // field, enum value or call signature
+	dirs string // directives of a call, aligned when printed
+}
+
+type file struct {
+	comments    []string // comments at the top of the file
+	attached    bool     // no blank line separates comments from the header
+	items       []item
+	endComments []string
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokPunct || t.kind == tokName) && t.text == text
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Line: t.line, Column: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(text string) (token, error) {
+	t := p.next()
+	if (t.kind != tokPunct && t.kind != tokName) || t.text != text {
+		return t, p.errorf(t, "expected %q, found %s", text, t)
+	}
+	return t, nil
+}
+
+func (p *parser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokName {
+		return "", p.errorf(t, "expected a name, found %s", t)
+	}
+	return t.text, nil
+}
+
+// leading collects the comments and descriptions before the next node.
+func (p *parser) leading() node {
+	var n node
+	first := true
+	for {
+		t := p.peek()
+		if first {
+			n.blankBefore = t.newlines >= 2
+			first = false
+		} else if t.newlines >= 2 && len(n.comments) > 0 {
+			n.comments = append(n.comments, "")
+		}
+		if t.kind != tokComment && t.kind != tokString {
+			return n
+		}
+		p.next()
+		n.comments = append(n.comments, t.text)
+	}
+}
+
+// trailing consumes a comment on the same line as the previous token.
+func (p *parser) trailing() string {
+	if t := p.peek(); t.kind == tokComment && t.newlines == 0 && p.pos > 0 {
+		p.next()
+		return t.text
+	}
+	return ""
+}
+
+func (p *parser) file() (*file, error) {
+	f := &file{}
+	for {
+		n := p.leading()
+		if p.peek().kind == tokEOF {
+			f.endComments = n.comments
+			return f, nil
+		}
+
+		// Comments at the top of the file, set apart by a blank line,
+		// describe the file rather than the first declaration.
+		if len(f.items) == 0 && f.comments == nil {
+			if last := len(n.comments) - 1; last >= 0 && n.comments[last] == "" {
+				f.comments = n.comments[:last]
+				n.comments = nil
+			}
+		}
+
+		it, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+
+		// So do comments right above the header directives, which may be
+		// reordered below them
+		if len(f.items) == 0 && f.comments == nil && it.kind == "directive" && len(n.comments) > 0 {
+			f.comments, f.attached = n.comments, true
+			n.comments = nil
+		}
+
+		it.node.comments = n.comments
+		it.node.blankBefore = n.blankBefore
+		it.node.trailing = p.trailing()
+		f.items = append(f.items, *it)
+	}
+}
+
+func (p *parser) item() (*item, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokPunct && t.text == "@":
+		d, name, err := p.directive()
+		if err != nil {
+			return nil, err
+		}
+		return &item{kind: "directive", name: name, header: d}, nil
+
+	case t.kind == tokName && t.text == "scalar":
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		return &item{kind: "scalar", name: name, header: join("scalar "+name, dirs)}, nil
+
+	case t.kind == tokName && (t.text == "type" || t.text == "input" || t.text == "enum"):
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		it := &item{kind: "block", name: name, header: join(t.text+" "+name, dirs)}
+		if !p.is("{") {
+			return it, nil
+		}
+		p.next()
+		it.hasBody = true
+		it.calls = t.text == "type" && name == "Calls"
+		it.openTrailing = p.trailing()
+		return it, p.body(it, t.text)
+	}
+	return nil, p.errorf(t, "expected a directive, scalar, type, input or enum, found %s", t)
+}
+
+// body parses block members up to and including the closing brace.
+func (p *parser) body(it *item, kind string) error {
+	for {
+		n := p.leading()
+		if p.is("}") {
+			p.next()
+			it.endComments = n.comments
+			return nil
+		}
+		if p.peek().kind == tokEOF {
+			return p.errorf(p.peek(), "expected \"}\", found end of file")
+		}
+
+		m := member{node: n}
+		var err error
+		switch {
+		case it.calls:
+			m.text, m.dirs, err = p.call()
+		case kind == "enum":
+			m.text, err = p.name()
+		default:
+			m.text, err = p.field()
+		}
+		if err != nil {
+			return err
+		}
+		if p.is(",") {
+			p.next()
+		}
+		m.trailing = p.trailing()
+		it.members = append(it.members, m)
+	}
+}
+
+// field parses `name: Type @directive...`.
+func (p *parser) field() (string, error) {
+	name, err := p.name()
+	if err != nil {
+		return "", err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return "", err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return "", err
+	}
+	dirs, err := p.directives()
+	if err != nil {
+		return "", err
+	}
+	return join(name+": "+typ, dirs), nil
+}
+
+// call parses `name(args): Type @method("/path")` and returns the
+// signature and the directives separately.
+func (p *parser) call() (string, string, error) {
+	name, err := p.name()
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := p.expect("("); err != nil {
+		return "", "", err
+	}
+
+	var args []string
+	for !p.is(")") {
+		if t := p.peek(); t.kind == tokComment || t.kind == tokString {
+			return "", "", p.errorf(t, "comments inside argument lists are not supported")
+		}
+		arg, err := p.name()
+		if err != nil {
+			return "", "", err
+		}
+		if _, err := p.expect(":"); err != nil {
+			return "", "", err
+		}
+		typ, err := p.typeRef()
+		if err != nil {
+			return "", "", err
+		}
+		arg += ": " + typ
+		if p.is("=") {
+			p.next()
+			v := p.next()
+			if v.kind != tokName && v.kind != tokString {
+				return "", "", p.errorf(v, "expected a default value, found %s", v)
+			}
+			arg += " = " + v.text
+		}
+		args = append(args, arg)
+		if p.is(",") {
+			p.next()
+		}
+	}
+	p.next()
+
+	if _, err := p.expect(":"); err != nil {
+		return "", "", err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return "", "", err
+	}
+	dirs, err := p.directives()
+	if err != nil {
+		return "", "", err
+	}
+	return name + "(" + strings.Join(args, ", ") + "): " + typ, strings.Join(dirs, " "), nil
+}
+
+// typeRef parses `Name`, `ns.Name`, `[Type]` with optional `!`s.
+func (p *parser) typeRef() (string, error) {
+	var s string
+	if p.is("[") {
+		p.next()
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if _, err := p.expect("]"); err != nil {
+			return "", err
+		}
+		s = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		s = name
+	}
+	if p.is("!") {
+		p.next()
+		s += "!"
+	}
+	return s, nil
+}
+
+// directives parses any directives that follow.
+func (p *parser) directives() ([]string, error) {
+	var dirs []string
+	for p.is("@") {
+		d, _, err := p.directive()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// directive parses `@name` or `@name(args)`.
+func (p *parser) directive() (string, string, error) {
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return "", "", err
+	}
+	d := "@" + name
+	if !p.is("(") {
+		return d, name, nil
+	}
+	p.next()
+
+	var b strings.Builder
+	b.WriteString(d + "(")
+	for !p.is(")") {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return "", "", p.errorf(t, "expected \")\", found end of file")
+		case t.kind == tokComment:
+			return "", "", p.errorf(t, "comments inside directives are not supported")
+		case t.text == ":" || t.text == ",":
+			b.WriteString(t.text + " ")
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	p.next()
+	b.WriteString(")")
+	return b.String(), name, nil
+}
+
+func join(head string, dirs []string) string {
+	if len(dirs) == 0 {
+		return head
+	}
+	return head + " " + strings.Join(dirs, " ")
+}
+
+// headerRank orders header directives.
+func headerRank(name string) int {
+	switch name {
+	case "base":
+		return 0
+	case "models":
+		return 1
+	case "library":
+		return 2
+	case "include":
+		return 3
+	}
+	return 4
+}
+
+func (f *file) print() string {
+	var b strings.Builder
+	writeComments(&b, "", f.comments)
+	if len(f.comments) > 0 && !f.attached {
+		b.WriteString("\n")
+	}
+
+	// Header directives, stably sorted by rank
+	var header, rest []item
+	for _, it := range f.items {
+		if it.kind == "directive" {
+			header = append(header, it)
+		} else {
+			rest = append(rest, it)
+		}
+	}
+	for rank := 0; rank <= 4; rank++ {
+		for _, it := range header {
+			if headerRank(it.name) != rank {
+				continue
+			}
+			writeComments(&b, "", it.comments)
+			b.WriteString(withTrailing(it.header, it.trailing) + "\n")
+		}
+	}
+
+	for i, it := range rest {
+		if i > 0 || len(header) > 0 {
+			together := it.kind == "scalar" && i > 0 && rest[i-1].kind == "scalar" && !it.blankBefore
+			if !together {
+				b.WriteString("\n")
+			}
+		}
+		writeComments(&b, "", it.comments)
+		if !it.hasBody {
+			b.WriteString(withTrailing(it.header, it.trailing) + "\n")
+			continue
+		}
+
+		b.WriteString(withTrailing(it.header+" {", it.openTrailing) + "\n")
+		writeMembers(&b, it)
+		writeComments(&b, "    ", trimBlank(it.endComments))
+		b.WriteString(withTrailing("}", it.trailing) + "\n")
+	}
+
+	if len(f.endComments) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeComments(&b, "", trimBlank(f.endComments))
+	}
+	return b.String()
+}
+
+func writeMembers(b *strings.Builder, it item) {
+	// Call directives are aligned within runs of calls not broken by a
+	// blank line.
+	width := make([]int, len(it.members))
+	if it.calls {
+		start := 0
+		for i := 0; i <= len(it.members); i++ {
+			if i == len(it.members) || i > start && it.members[i].blankBefore {
+				w := 0
+				for _, m := range it.members[start:i] {
+					w = max(w, len(m.text))
+				}
+				for j := start; j < i; j++ {
+					width[j] = w
+				}
+				start = i
+			}
+		}
+	}
+
+	for i, m := range it.members {
+		if i > 0 && m.blankBefore {
+			b.WriteString("\n")
+		}
+		writeComments(b, "    ", m.comments)
+		line := m.text
+		if m.dirs != "" {
+			line += strings.Repeat(" ", width[i]-len(m.text)+1) + m.dirs
+		}
+		b.WriteString("    " + withTrailing(line, m.trailing) + "\n")
+	}
+}
+
+func writeComments(b *strings.Builder, indent string, comments []string) {
+	for _, c := range comments {
+		if c == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(reindent(c, indent) + "\n")
+	}
+}
+
+// reindent indents a comment or description. The lines after the first of
+// a block string keep their indentation relative to each other.
+func reindent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	common := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = indent + line
+		case strings.TrimSpace(line) == "":
+			lines[i] = ""
+		default:
+			lines[i] = indent + line[common:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func withTrailing(s, comment string) string {
+	if comment == "" {
+		return s
+	}
+	return s + " " + comment
+}
+
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
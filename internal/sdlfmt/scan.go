@@ -0,0 +1,128 @@
+package sdlfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF     tokenKind = iota
+	tokName              // identifiers, namespaced names (geo.Location) and numbers
+	tokString            // "..." or """..."""
+	tokComment           // # to end of line
+	tokPunct             // { } ( ) [ ] : ! , = @
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	col  int
+
+	// newlines is the number of line breaks between the previous token
+	// and this one.
+	newlines int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of file"
+	case tokComment:
+		return "comment"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// scan splits src into tokens, keeping comments.
+func scan(src string) ([]token, error) {
+	var toks []token
+	line, col := 1, 1
+	newlines := 0
+
+	advance := func(n int) {
+		for _, r := range src[:n] {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+		src = src[n:]
+	}
+
+	for len(src) > 0 {
+		c := src[0]
+		switch {
+		case c == '\n':
+			newlines++
+			advance(1)
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			advance(1)
+			continue
+		}
+
+		t := token{line: line, col: col, newlines: newlines}
+		newlines = 0
+
+		var n int
+		switch {
+		case c == '#':
+			n = strings.IndexByte(src, '\n')
+			if n < 0 {
+				n = len(src)
+			}
+			t.kind = tokComment
+			t.text = strings.TrimRight(src[:n], " \t\r")
+		case strings.HasPrefix(src, `"""`):
+			end := strings.Index(src[3:], `"""`)
+			if end < 0 {
+				return nil, &Error{Line: line, Column: col, Msg: "unterminated block string"}
+			}
+			n = end + 6
+			t.kind = tokString
+			t.text = src[:n]
+		case c == '"':
+			n = 1
+			for n < len(src) && src[n] != '"' && src[n] != '\n' {
+				if src[n] == '\\' {
+					n++
+				}
+				n++
+			}
+			if n >= len(src) || src[n] != '"' {
+				return nil, &Error{Line: line, Column: col, Msg: "unterminated string"}
+			}
+			n++
+			t.kind = tokString
+			t.text = src[:n]
+		case strings.IndexByte("{}()[]:!,=@", c) >= 0:
+			n = 1
+			t.kind = tokPunct
+			t.text = src[:1]
+		case isNameByte(c) || c == '-' && len(src) > 1 && isNameByte(src[1]):
+			n = 1
+			for n < len(src) && (isNameByte(src[n]) || src[n] == '.' && n+1 < len(src) && isNameByte(src[n+1])) {
+				n++
+			}
+			t.kind = tokName
+			t.text = src[:n]
+		default:
+			return nil, &Error{Line: line, Column: col, Msg: fmt.Sprintf("unexpected character %q", c)}
+		}
+
+		toks = append(toks, t)
+		advance(n)
+	}
+
+	toks = append(toks, token{kind: tokEOF, line: line, col: col, newlines: newlines})
+	return toks, nil
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
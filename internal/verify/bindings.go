@@ -0,0 +1,195 @@
+package verify
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// Binding is an SDL type or input bound to an existing Go type with
+// @goModel.
+type Binding struct {
+	Source     string // SDL file declaring it
+	Node       string // e.g. "type Money"
+	Pos        schema.Pos
+	ImportPath string // package of the Go type
+	TypeName   string // name of the Go type in that package
+	Fields     []schema.Field
+}
+
+// CheckBindings loads the packages of the bound Go types and checks that
+// each type exists and, when the SDL lists fields, that every field has a
+// Go field with the same JSON name and a compatible shape. Errors point at
+// the SDL.
+func CheckBindings(bindings []Binding) ([]Error, error) {
+	if len(bindings) == 0 {
+		return nil, nil
+	}
+
+	paths := make(map[string]bool)
+	for _, b := range bindings {
+		paths[b.ImportPath] = true
+	}
+	var patterns []string
+	for p := range paths {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading bound packages: %w", err)
+	}
+	byPath := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		byPath[pkg.PkgPath] = pkg
+	}
+
+	var errs []Error
+	for _, b := range bindings {
+		report := func(pos schema.Pos, format string, args ...any) {
+			errs = append(errs, Error{
+				File:   b.Source,
+				Line:   pos.Line,
+				Column: pos.Column,
+				Msg:    fmt.Sprintf(format, args...),
+				Node:   b.Node,
+			})
+		}
+
+		pkg := byPath[b.ImportPath]
+		if pkg == nil || pkg.Types == nil || len(pkg.Errors) > 0 {
+			report(b.Pos, "@goModel package %s could not be loaded", b.ImportPath)
+			continue
+		}
+
+		obj, ok := pkg.Types.Scope().Lookup(b.TypeName).(*types.TypeName)
+		if !ok {
+			report(b.Pos, "@goModel type %s.%s does not exist", b.ImportPath, b.TypeName)
+			continue
+		}
+
+		if len(b.Fields) == 0 {
+			continue
+		}
+
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			report(b.Pos, "@goModel type %s.%s is not a struct, but the SDL lists fields", b.ImportPath, b.TypeName)
+			continue
+		}
+
+		goFields := jsonFields(st)
+		for _, f := range b.Fields {
+			gf, ok := goFields[strings.ToLower(f.Name)]
+			if !ok {
+				report(f.Pos, "field %s has no JSON counterpart in %s.%s", f.Name, b.ImportPath, b.TypeName)
+				continue
+			}
+			if msg := compatible(f, gf.Type()); msg != "" {
+				report(f.Pos, "field %s: %s (Go field %s is %s)", f.Name, msg, gf.Name(), types.TypeString(gf.Type(), types.RelativeTo(pkg.Types)))
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// jsonFields returns the fields of st encoding/json would use, keyed by
+// lower-cased JSON name (encoding/json matches names case-insensitively),
+// including those promoted from embedded structs.
+func jsonFields(st *types.Struct) map[string]*types.Var {
+	fields := make(map[string]*types.Var)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" && tag == "-" {
+			continue
+		}
+
+		if f.Embedded() && name == "" {
+			t := f.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if inner, ok := t.Underlying().(*types.Struct); ok {
+				for k, v := range jsonFields(inner) {
+					if _, exists := fields[k]; !exists {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if !f.Exported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name()
+		}
+		fields[strings.ToLower(name)] = f
+	}
+	return fields
+}
+
+// compatible checks an SDL field against a Go field type, returning why
+// they don't match, or "".
+func compatible(f schema.Field, t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	if f.IsList {
+		switch u := t.Underlying().(type) {
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		default:
+			return "SDL list but Go field is not a slice"
+		}
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+	} else if _, ok := t.Underlying().(*types.Slice); ok && !isBytes(t) {
+		return "Go field is a slice but SDL is not a list"
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	switch f.Type {
+	case "String":
+		if !ok || basic.Info()&types.IsString == 0 {
+			return "SDL String needs a string"
+		}
+	case "Int":
+		if !ok || basic.Info()&types.IsInteger == 0 {
+			return "SDL Int needs an integer"
+		}
+	case "Float":
+		if !ok || basic.Info()&types.IsNumeric == 0 {
+			return "SDL Float needs a number"
+		}
+	case "Boolean":
+		if !ok || basic.Info()&types.IsBoolean == 0 {
+			return "SDL Boolean needs a bool"
+		}
+	}
+	return ""
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
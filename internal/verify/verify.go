@@ -0,0 +1,249 @@
+package verify
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// Error is a type-checking error in generated code, mapped back to the SDL
+// node that produced it where possible.
+type Error struct {
+	File   string // generated file
+	Line   int
+	Column int
+	Msg    string
+	Source string // SDL file that produced the generated file
+	Node   string // SDL node, e.g. "call getContact" or "type Contact"
+}
+
+func (e Error) Error() string {
+	loc := fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	if e.Source == "" {
+		return loc
+	}
+	if e.Node == "" {
+		return e.Source + ": " + loc
+	}
+	return e.Source + " (" + e.Node + "): " + loc
+}
+
+// File is a generated file to type-check.
+type File struct {
+	Path    string
+	Content []byte
+	Source  string         // SDL file that produced it
+	Schema  *schema.Schema // parsed SDL, used to map errors back to it
+}
+
+// Verify loads the packages that the generated files belong to, overlaying
+// the generated content on top of what is on disk, and type-checks them.
+// Errors inside generated files are mapped back to the SDL that produced them.
+func Verify(files []File) ([]Error, error) {
+	overlay := make(map[string][]byte)
+	byPath := make(map[string]File)
+	dirs := make(map[string]bool)
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Path, ".go") {
+			continue
+		}
+		abs, err := filepath.Abs(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", f.Path, err)
+		}
+		overlay[abs] = f.Content
+		byPath[abs] = f
+		dirs[filepath.Dir(abs)] = true
+	}
+
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	var patterns []string
+	for dir := range dirs {
+		patterns = append(patterns, dir)
+	}
+	sort.Strings(patterns)
+
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Overlay: overlay,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+
+	roots := make(map[string]bool)
+	for _, pkg := range pkgs {
+		roots[pkg.PkgPath] = true
+	}
+
+	var errs []Error
+	seen := make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		for _, pe := range pkg.Errors {
+			// A root that fails to compile also shows up as a build error
+			// in every root importing it; report it once, from the source.
+			if pe.Kind == packages.ListError && strings.HasPrefix(pe.Msg, "# ") {
+				firstLine, _, _ := strings.Cut(strings.TrimPrefix(pe.Msg, "# "), "\n")
+				if roots[strings.TrimSpace(firstLine)] {
+					continue
+				}
+			}
+
+			file, line, col := splitPos(pe.Pos)
+			if seen[pe.Error()] {
+				continue
+			}
+			seen[pe.Error()] = true
+
+			e := Error{File: file, Line: line, Column: col, Msg: pe.Msg}
+
+			abs, _ := filepath.Abs(file)
+			if f, ok := byPath[abs]; ok {
+				e.File = f.Path
+				e.Source = f.Source
+				e.Node = nodeAt(f, line)
+			} else if file == "" {
+				// Package-level errors (e.g. no go.mod) have no position
+				e.File = pkg.PkgPath
+			}
+
+			errs = append(errs, e)
+		}
+	}
+
+	return errs, nil
+}
+
+// splitPos splits a "file:line:col" position as reported by go/packages.
+func splitPos(pos string) (file string, line, col int) {
+	if pos == "" || pos == "-" {
+		return "", 0, 0
+	}
+
+	parts := strings.Split(pos, ":")
+	// Peel numeric suffixes off the end; the remainder is the file name
+	var nums []int
+	for len(parts) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+
+	file = strings.Join(parts, ":")
+	if len(nums) > 0 {
+		line = nums[0]
+	}
+	if len(nums) > 1 {
+		col = nums[1]
+	}
+	return file, line, col
+}
+
+// nodeAt finds the declaration enclosing line in the generated file and maps
+// it back to the SDL call, type, input or enum that produced it.
+func nodeAt(f File, line int) string {
+	if f.Schema == nil || line == 0 {
+		return ""
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, f.Path, f.Content, parser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+
+	for _, decl := range file.Decls {
+		start := fset.Position(decl.Pos()).Line
+		end := fset.Position(decl.End()).Line
+		if line < start || line > end {
+			continue
+		}
+
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil {
+				for _, c := range f.Schema.Calls {
+					if c.HandlerName() == d.Name.Name {
+						return "call " + c.Name
+					}
+				}
+				// Methods on enum types (IsValid, String)
+				if name := receiverName(d.Recv); name != "" {
+					return declNode(f.Schema, name)
+				}
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if node := declNode(f.Schema, sp.Name.Name); node != "" {
+						return node
+					}
+				case *ast.ValueSpec:
+					// Enum constants: ContactStatusActive ContactStatus = "active"
+					if ident, ok := sp.Type.(*ast.Ident); ok {
+						if node := declNode(f.Schema, ident.Name); node != "" {
+							return node
+						}
+					}
+				}
+			}
+		}
+		return ""
+	}
+
+	return ""
+}
+
+// declNode describes the SDL definition with the given name.
+func declNode(s *schema.Schema, name string) string {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return "type " + name
+		}
+	}
+	for _, t := range s.Inputs {
+		if t.Name == name {
+			return "input " + name
+		}
+	}
+	for _, e := range s.Enums {
+		if e.Name == name {
+			return "enum " + name
+		}
+	}
+	return ""
+}
+
+func receiverName(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
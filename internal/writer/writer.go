@@ -0,0 +1,173 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Transaction stages a set of file writes and commits them all-or-nothing.
+// Each file is written to a temp file next to its destination and then
+// renamed into place, so readers never observe a partially written file.
+// If any step fails, files already committed are restored to their
+// original content and newly created directories are removed.
+type Transaction struct {
+	files []stagedFile
+}
+
+type stagedFile struct {
+	path    string
+	content []byte
+	tmpPath string
+
+	// original state, captured before commit for rollback
+	existed  bool
+	original []byte
+	mode     os.FileMode
+}
+
+// New creates an empty transaction.
+func New() *Transaction {
+	return &Transaction{}
+}
+
+// Add stages content to be written to path.
+func (t *Transaction) Add(path string, content []byte) {
+	t.files = append(t.files, stagedFile{path: path, content: content})
+}
+
+// Commit writes all staged files. On failure the tree is left as it was.
+func (t *Transaction) Commit() (err error) {
+	var createdDirs []string
+	committed := 0
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		t.rollback(committed)
+		// Remove directories we created, deepest first
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			os.Remove(createdDirs[i])
+		}
+	}()
+
+	// Capture original state so we can restore it
+	for i := range t.files {
+		f := &t.files[i]
+		f.mode = 0644
+		info, statErr := os.Stat(f.path)
+		if statErr == nil {
+			original, readErr := os.ReadFile(f.path)
+			if readErr != nil {
+				return fmt.Errorf("reading %s: %w", f.path, readErr)
+			}
+			f.existed = true
+			f.original = original
+			f.mode = info.Mode().Perm()
+		} else if !os.IsNotExist(statErr) {
+			return fmt.Errorf("stat %s: %w", f.path, statErr)
+		}
+	}
+
+	// Stage: write every file to a temp file in its destination directory
+	for i := range t.files {
+		f := &t.files[i]
+		dirs, mkErr := mkdirAll(filepath.Dir(f.path))
+		createdDirs = append(createdDirs, dirs...)
+		if mkErr != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(f.path), mkErr)
+		}
+
+		tmpPath, writeErr := writeTemp(f.path, f.content, f.mode)
+		if writeErr != nil {
+			return fmt.Errorf("staging %s: %w", f.path, writeErr)
+		}
+		f.tmpPath = tmpPath
+	}
+
+	// Commit: atomically rename each temp file into place
+	for i := range t.files {
+		f := &t.files[i]
+		if renameErr := os.Rename(f.tmpPath, f.path); renameErr != nil {
+			return fmt.Errorf("writing %s: %w", f.path, renameErr)
+		}
+		f.tmpPath = ""
+		committed++
+	}
+
+	return nil
+}
+
+// rollback restores the first n committed files and removes leftover temps.
+func (t *Transaction) rollback(n int) {
+	for i := range t.files {
+		f := &t.files[i]
+		if f.tmpPath != "" {
+			os.Remove(f.tmpPath)
+			f.tmpPath = ""
+		}
+		if i >= n {
+			continue
+		}
+		if !f.existed {
+			os.Remove(f.path)
+			continue
+		}
+		if tmpPath, err := writeTemp(f.path, f.original, f.mode); err == nil {
+			if err := os.Rename(tmpPath, f.path); err != nil {
+				os.Remove(tmpPath)
+			}
+		}
+	}
+}
+
+// writeTemp writes content to a temp file next to path and returns its name.
+func writeTemp(path string, content []byte, mode os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".restgen-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+// mkdirAll is like os.MkdirAll but returns the directories it created,
+// outermost first, so they can be removed on rollback.
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil && !os.IsExist(err) {
+			return created, err
+		}
+		created = append(created, missing[i])
+	}
+
+	return created, nil
+}
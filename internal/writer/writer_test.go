@@ -0,0 +1,117 @@
+package writer
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]string // files present before the commit
+		add      []string          // staged files, in order; content is "new <path>"
+		wantErr  bool
+	}{
+		{
+			name:     "writes new and existing files",
+			existing: map[string]string{"routes/a.go": "old a"},
+			add:      []string{"routes/a.go", "models/deep/b.go"},
+		},
+		{
+			// out/f becomes a directory while staging out/f/g, so renaming
+			// onto it fails after the first two files are committed
+			name:     "rename fails after others are committed",
+			existing: map[string]string{"routes/a.go": "old a"},
+			add:      []string{"routes/a.go", "models/deep/b.go", "out/f", "out/f/g"},
+			wantErr:  true,
+		},
+		{
+			name:     "staging fails under a file",
+			existing: map[string]string{"routes/a.go": "old a", "blocker": "file"},
+			add:      []string{"routes/a.go", "models/b.go", "blocker/c.go"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for path, content := range tt.existing {
+				writeFile(t, filepath.Join(dir, path), content)
+			}
+			before := snapshot(t, dir)
+
+			tx := New()
+			for _, path := range tt.add {
+				tx.Add(filepath.Join(dir, path), []byte("new "+path))
+			}
+			err := tx.Commit()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Commit succeeded, want an error")
+				}
+				// Restored files, no temps left and created directories removed
+				if after := snapshot(t, dir); !maps.Equal(after, before) {
+					t.Errorf("tree after rollback = %v, want %v", after, before)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			want := maps.Clone(before)
+			for _, path := range tt.add {
+				want[path] = "new " + path
+				for d := filepath.Dir(path); d != "."; d = filepath.Dir(d) {
+					want[d+"/"] = ""
+				}
+			}
+			if after := snapshot(t, dir); !maps.Equal(after, want) {
+				t.Errorf("tree after commit = %v, want %v", after, want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snapshot returns every file under dir with its content, and every
+// directory with a trailing slash, by path relative to dir.
+func snapshot(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	tree := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			tree[rel+"/"] = ""
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		tree[rel] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/borderlesshq/restgen/gen"
+)
+
+// runIR prints the JSON IR of every configured schema, the same document
+// plugins receive on stdin.
+func runIR(args []string) error {
+	fs := flag.NewFlagSet("ir", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	output := fs.String("o", "", "write the IR to this file instead of stdout")
+	targetName := fs.String("target", "", "target to describe (required when the config has several)")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return err
+	}
+
+	target, err := selectTarget(cfg, *targetName)
+	if err != nil {
+		return err
+	}
+
+	// Positional arguments restrict the IR to those schema files
+	doc, err := gen.BuildIR(gen.OSFS{}, target, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding IR: %w", err)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// selectTarget returns the target named name, or the only target when name
+// is empty.
+func selectTarget(cfg *gen.Config, name string) (*gen.Config, error) {
+	targets, err := gen.Targets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name != "":
+		for _, t := range targets {
+			if t.Name == name {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("no target named %q", name)
+	case len(targets) == 1:
+		return targets[0], nil
+	default:
+		return nil, fmt.Errorf("config has %d targets; choose one with -target", len(targets))
+	}
+}
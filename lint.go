@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/lint"
+	"github.com/borderlesshq/restgen/internal/parser"
+)
+
+// runLint checks the configured schemas against the lint rules. It returns
+// failed=true if any diagnostic has error severity.
+func runLint(args []string) (failed bool, err error) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		return false, fmt.Errorf("unknown format %q (want text, json or sarif)", *format)
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return false, err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		if paths, err = gen.SchemaFiles(gen.OSFS{}, cfg); err != nil {
+			return false, err
+		}
+	}
+
+	files, err := lintFiles(paths)
+	if err != nil {
+		return false, err
+	}
+
+	diags, err := lint.Run(files, cfg.Lint)
+	if err != nil {
+		return false, err
+	}
+
+	switch *format {
+	case "json":
+		if diags == nil {
+			diags = []lint.Diagnostic{}
+		}
+		data, err := json.MarshalIndent(diags, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("encoding diagnostics: %w", err)
+		}
+		fmt.Println(string(data))
+	case "sarif":
+		data, err := lint.SARIF(diags, cfg.Lint, restgenVersion())
+		if err != nil {
+			return false, fmt.Errorf("encoding diagnostics: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Print(lint.Text(diags))
+	}
+
+	return lint.HasErrors(diags), nil
+}
+
+// lintFiles parses paths and every file they include.
+func lintFiles(paths []string) ([]lint.File, error) {
+	p := parser.NewWithReader(os.ReadFile)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []lint.File
+	seen := make(map[string]bool)
+	var add func(path string) error
+	add = func(path string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if seen[abs] {
+			return nil
+		}
+		seen[abs] = true
+
+		s, err := p.ParseFile(abs)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		src, err := os.ReadFile(abs)
+		if err != nil {
+			return err
+		}
+		display, err := filepath.Rel(cwd, abs)
+		if err != nil {
+			display = abs
+		}
+		files = append(files, lint.File{Path: filepath.ToSlash(display), Abs: abs, Source: src, Schema: s})
+
+		for _, inc := range s.Includes {
+			if err := add(inc.Resolved); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := add(path); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/borderlesshq/restgen/internal/lsp"
+)
+
+// runLSP serves the Language Server Protocol over stdin and stdout until
+// the editor exits.
+func runLSP(args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	// Editors such as VS Code pass --stdio; it is the only transport
+	fs.Bool("stdio", true, "communicate over stdin and stdout")
+	fs.Parse(args)
+
+	return lsp.NewServer(os.Stdin, os.Stdout, restgenVersion()).Run()
+}
@@ -1,17 +1,15 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-
-	"github.com/borderlesshq/restgen/internal/config"
-	"github.com/borderlesshq/restgen/internal/emitter"
-	"github.com/borderlesshq/restgen/internal/merger"
-	"github.com/borderlesshq/restgen/internal/parser"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/cache"
 )
 
 func main() {
@@ -26,6 +24,88 @@ func main() {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
+	case "check":
+		stale, err := runCheck(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if stale {
+			os.Exit(1)
+		}
+	case "fmt":
+		unformatted, err := runFmt(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if unformatted {
+			os.Exit(1)
+		}
+	case "lint":
+		failed, err := runLint(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	case "routes":
+		conflicting, err := runRoutes(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if conflicting {
+			os.Exit(1)
+		}
+	case "explain":
+		if err := runExplain(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "lsp":
+		if err := runLSP(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "diff":
+		breaking, err := runDiff(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if breaking {
+			os.Exit(1)
+		}
+	case "watch":
+		if err := runWatch(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "ir":
+		if err := runIR(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "templates":
+		if err := runTemplates(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "import":
+		if err := runImport(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "schema":
+		if err := runSchema(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "version":
+		runVersion()
 	case "init":
 		if err := runInit(); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -42,7 +122,32 @@ func printUsage() {
 
 Usage:
   restgen generate [-c config.yaml]    Generate routes from schemas
+           [-verify]                   Type-check generated code before writing
+           [-force]                    Ignore the cache and regenerate everything
+           [-j N]                      Process N schemas in parallel (default: CPUs)
+  restgen check [-c config.yaml]       Fail if generated files are out of date
+  restgen watch [-c config.yaml]       Regenerate on schema or config changes
+  restgen fmt [-w] [-l] [file|dir|-...]
+                                       Format SDL in the canonical layout
+  restgen lint [-format text|json|sarif] [schema...]
+                                       Check schemas against the lint rules
+  restgen routes [-format table|json] [-method M] [-prefix /p] [-schema glob]
+                                       Print every endpoint and flag conflicting routes
+  restgen explain [-format text|json] file.sdl call
+                                       Show how a call's arguments are bound and its stub
+  restgen lsp                          Serve the Language Server Protocol over stdio
+  restgen diff [-format text|json] (old/ new/ | -against git-ref)
+                                       Report changes, failing on breaking ones
+  restgen ir [-c config.yaml] [-target name] [-o out.json] [schema...]
+                                       Print the JSON IR that plugins receive
+  restgen templates export [-o dir]    Write the built-in templates for customizing
+  restgen schema [-o file]             Print the JSON Schema for restgen.yaml
+  restgen import go [-o dir] [-bind] packages...
+                                       Write SDL for Go structs, enums and chi routes
+  restgen import openapi [-o dir] spec.yaml
+                                       Write SDL for an OpenAPI 3 document
   restgen init                         Initialize with example config and schema
+  restgen version                      Print the restgen version
 
 Options:
   -c, --config    Path to config file (default: restgen.yaml)`)
@@ -52,189 +157,186 @@ func runGenerate(args []string) error {
 	fs := flag.NewFlagSet("generate", flag.ExitOnError)
 	configPath := fs.String("c", "restgen.yaml", "config file path")
 	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	verifyTypes := fs.Bool("verify", false, "type-check generated code before writing")
+	force := fs.Bool("force", false, "ignore the cache and regenerate everything")
+	jobs := fs.Int("j", 0, "number of schemas to process in parallel (default: number of CPUs)")
 	fs.Parse(args)
 
 	// Load config
-	cfg, err := config.Load(*configPath)
+	cfg, err := loadConfig(fs, *configPath)
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
-	}
-
-	// Find schema files
-	var schemaFiles []string
-	for _, pattern := range cfg.Schemas {
-		matches, err := filepath.Glob(pattern)
-		if err != nil {
-			return fmt.Errorf("glob pattern %s: %w", pattern, err)
-		}
-		schemaFiles = append(schemaFiles, matches...)
+		return err
 	}
 
-	if len(schemaFiles) == 0 {
-		return fmt.Errorf("no schema files found matching patterns: %v", cfg.Schemas)
-	}
-
-	// Process each schema
-	p := parser.New()
-	routesEmitter := emitter.NewRoutesEmitter(cfg)
-	typesEmitter := emitter.NewTypesEmitter(cfg)
-	depsEmitter := emitter.NewDependenciesEmitter(cfg.Package)
-	m := merger.New()
-
-	// Track directories to format
-	dirsToFormat := make(map[string]bool)
-	dirsToFormat[cfg.Output] = true
-
-	// Ensure output directory exists
-	if err := os.MkdirAll(cfg.Output, 0755); err != nil {
-		return fmt.Errorf("creating output dir: %w", err)
+	targets, err := gen.Targets(cfg)
+	if err != nil {
+		return err
 	}
 
-	// Generate dependencies.go once (if it doesn't exist)
-	depsFile := filepath.Join(cfg.Output, "dependencies.go")
-	if _, err := os.Stat(depsFile); os.IsNotExist(err) {
-		depsContent, err := depsEmitter.Emit()
+	// Find schema files, per target
+	targetFiles := make([][]string, len(targets))
+	for i, t := range targets {
+		targetFiles[i], err = gen.SchemaFiles(gen.OSFS{}, t)
 		if err != nil {
-			return fmt.Errorf("emitting dependencies: %w", err)
-		}
-		if err := os.WriteFile(depsFile, []byte(depsContent), 0644); err != nil {
-			return fmt.Errorf("writing %s: %w", depsFile, err)
+			if t.Name != "" {
+				return fmt.Errorf("target %s: %w", t.Name, err)
+			}
+			return err
 		}
-		fmt.Printf("→ %s (new)\n", depsFile)
 	}
 
-	for _, schemaFile := range schemaFiles {
-		fmt.Printf("Processing %s...\n", schemaFile)
-
-		schema, err := p.ParseFile(schemaFile)
-		if err != nil {
-			return fmt.Errorf("parsing %s: %w", schemaFile, err)
-		}
-
-		// Use default models package from config if not specified in SDL
-		if schema.Models == "" && cfg.Models.Package != "" {
-			schema.Models = cfg.Models.Package
-		}
-
-		// Derive handler name for this schema
-		baseName := strings.TrimSuffix(filepath.Base(schemaFile), ".sdl")
-
-		// Only generate routes if there are Calls defined
-		if len(schema.Calls) > 0 {
-			// Generate routes
-			routesContent, err := routesEmitter.Emit(schema)
-			if err != nil {
-				return fmt.Errorf("emitting routes for %s: %w", schemaFile, err)
-			}
-
-			// Output routes file
-			routesFile := filepath.Join(cfg.Output, baseName+"_routes.go")
-
-			// Merge with existing if present
-			result, err := m.Merge(routesContent, routesFile)
-			if err != nil {
-				return fmt.Errorf("merging %s: %w", routesFile, err)
-			}
-
-			if err := os.WriteFile(routesFile, []byte(result.Content), 0644); err != nil {
-				return fmt.Errorf("writing %s: %w", routesFile, err)
+	// Skip schemas whose inputs and outputs haven't changed since last run
+	c := cache.Load(cache.DefaultPath)
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("hashing config: %w", err)
+	}
+	cacheInputs := append([][]byte{cfgJSON, []byte(restgenVersion())}, templateInputs(cfg)...)
+
+	// Plugins see every schema, so the cache can't tell whether their
+	// output is current.
+	useCache := !*force && len(cfg.Plugins) == 0
+
+	// A schema that is stale in any target is regenerated in all of them.
+	// The cache doesn't track dependencies.go, so a target missing it is
+	// regenerated to create it again.
+	stale := make(map[string]bool)
+	for i, t := range targets {
+		_, err := os.Stat(gen.DependenciesFile(t))
+		missingDeps := errors.Is(err, os.ErrNotExist)
+		for _, schemaFile := range targetFiles[i] {
+			if !useCache || missingDeps || !c.Fresh(t.Name, schemaFile, cacheInputs...) {
+				stale[schemaFile] = true
 			}
-			fmt.Printf("  → %s\n", routesFile)
+		}
+	}
 
-			if len(result.PreservedMethods) > 0 {
-				fmt.Printf("    preserved: %v\n", result.PreservedMethods)
+	var staleFiles []string
+	seen := make(map[string]bool)
+	for _, files := range targetFiles {
+		for _, schemaFile := range files {
+			if seen[schemaFile] {
+				continue
 			}
-			if len(result.RemovedMethods) > 0 {
-				fmt.Printf("    removed: %v\n", result.RemovedMethods)
+			seen[schemaFile] = true
+			if !stale[schemaFile] {
+				fmt.Printf("Skipping %s (unchanged)\n", schemaFile)
+				continue
 			}
+			staleFiles = append(staleFiles, schemaFile)
 		}
+	}
 
-		// Generate types if models path specified (from SDL or config default)
-		if schema.Models != "" {
-			// Only generate types if there are types, inputs, or enums defined
-			if len(schema.Types) > 0 || len(schema.Inputs) > 0 || len(schema.Enums) > 0 {
-				typesContent, err := typesEmitter.Emit(schema)
-				if err != nil {
-					return fmt.Errorf("emitting types for %s: %w", schemaFile, err)
-				}
-
-				// Derive types output path from models package
-				// e.g., github.com/borderlesshq/api/models -> models/
-				modelsParts := strings.Split(schema.Models, "/")
-				modelsDir := modelsParts[len(modelsParts)-1]
-				typesFile := filepath.Join(modelsDir, baseName+"_types.go")
+	if len(staleFiles) == 0 {
+		fmt.Println("Everything is up to date.")
+		return nil
+	}
 
-				if err := os.MkdirAll(modelsDir, 0755); err != nil {
-					return fmt.Errorf("creating models dir: %w", err)
-				}
+	res, err := generateFiles(gen.Options{
+		Config:      cfg,
+		SchemaFiles: staleFiles,
+		Workers:     *jobs,
+		Verify:      *verifyTypes,
+	})
+	if err != nil {
+		return err
+	}
 
-				if err := os.WriteFile(typesFile, []byte(typesContent), 0644); err != nil {
-					return fmt.Errorf("writing %s: %w", typesFile, err)
+	// Record what each regenerated schema produced
+	for i, t := range targets {
+		for _, schemaFile := range targetFiles[i] {
+			if !stale[schemaFile] {
+				continue
+			}
+			outputs := make(map[string][]byte)
+			for _, f := range res.Written {
+				if f.Source == schemaFile && f.Target == t.Name {
+					outputs[f.Path] = f.Content
 				}
-				fmt.Printf("  → %s\n", typesFile)
-
-				dirsToFormat[modelsDir] = true
 			}
+			c.Update(t.Name, schemaFile, res.Includes[schemaFile], outputs, cacheInputs...)
 		}
 	}
-
-	// Format generated files with goimports
-	fmt.Println("Formatting generated files...")
-	for dir := range dirsToFormat {
-		if err := runGoimports(dir); err != nil {
-			fmt.Printf("  warning: goimports on %s failed: %v\n", dir, err)
-		}
+	if err := c.Save(cache.DefaultPath); err != nil {
+		fmt.Printf("  warning: saving cache: %v\n", err)
 	}
 
 	fmt.Println("Done!")
 	return nil
 }
 
-// runGoimports runs goimports on the given directory to format code and fix imports.
-// Falls back to gofmt if goimports is not available.
-func runGoimports(dir string) error {
-	// Check if goimports is available
-	goimportsPath, err := exec.LookPath("goimports")
-	if err != nil {
-		// Check in GOPATH/bin
-		gopath := os.Getenv("GOPATH")
-		if gopath == "" {
-			home, _ := os.UserHomeDir()
-			gopath = filepath.Join(home, "go")
+// loadConfig loads the config named by -c. Without -c, a missing
+// restgen.yaml means the defaults; with it, a missing file is an error.
+func loadConfig(fs *flag.FlagSet, path string) (*gen.Config, error) {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "c" || f.Name == "config" {
+			explicit = true
 		}
-		goimportsPath = filepath.Join(gopath, "bin", "goimports")
-		if _, err := os.Stat(goimportsPath); err != nil {
-			// goimports not found, fall back to gofmt
-			fmt.Printf("  goimports not found, using gofmt (run 'go install golang.org/x/tools/cmd/goimports@latest' for better formatting)\n")
-			return runGofmt(dir)
+	})
+
+	var (
+		cfg *gen.Config
+		err error
+	)
+	if explicit {
+		cfg, err = gen.Load(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("config file %s does not exist", path)
 		}
+	} else {
+		cfg, err = gen.LoadOrDefault(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
 	}
+	return cfg, nil
+}
 
-	// Run goimports -w on the directory
-	cmd := exec.Command(goimportsPath, "-w", dir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("running goimports: %w", err)
+// generateFiles runs gen.Generate and reports what was written.
+// Nothing is written unless every output is valid.
+func generateFiles(opts gen.Options) (*gen.Result, error) {
+	for _, schemaFile := range opts.SchemaFiles {
+		fmt.Printf("Processing %s...\n", schemaFile)
+	}
+	if opts.Verify {
+		fmt.Println("Type-checking generated code...")
 	}
 
-	return nil
-}
+	res, err := gen.Generate(context.Background(), opts)
+	if err != nil {
+		var verr *gen.VerifyError
+		if errors.As(err, &verr) {
+			for _, e := range verr.Errors {
+				fmt.Fprintf(os.Stderr, "  %s\n", e)
+			}
+			return nil, fmt.Errorf("%w, nothing was written", err)
+		}
+		return nil, err
+	}
+
+	for _, f := range res.Written {
+		if f.CreateOnly {
+			fmt.Printf("→ %s (new)\n", f.Path)
+			continue
+		}
+		fmt.Printf("  → %s\n", f.Path)
 
-// runGofmt runs gofmt as a fallback when goimports is not available.
-func runGofmt(dir string) error {
-	cmd := exec.Command("gofmt", "-w", dir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("running gofmt: %w", err)
+		if len(f.PreservedMethods) > 0 {
+			fmt.Printf("    preserved: %v\n", f.PreservedMethods)
+		}
+		if len(f.RemovedMethods) > 0 {
+			fmt.Printf("    removed: %v\n", f.RemovedMethods)
+		}
 	}
-	return nil
+
+	return res, nil
 }
 
 func runInit() error {
 	// Create example config
-	configContent := `# restgen configuration
+	configContent := `# yaml-language-server: $schema=./restgen.schema.json
+# restgen configuration
 package: routes
 output: ./routes
 
@@ -245,7 +347,10 @@ models:
 scalars:
   Time: time.Time
   ID: string
-  Decimal: decimal.Decimal
+  Decimal:
+    type: Decimal
+    import: github.com/shopspring/decimal
+    parse: decimal.NewFromString
 
 schemas:
   - ./schemas/*.sdl
@@ -302,6 +407,11 @@ type DeleteResult {
 	}
 	fmt.Println("Created restgen.yaml")
 
+	if err := os.WriteFile("restgen.schema.json", gen.ConfigSchema(), 0644); err != nil {
+		return fmt.Errorf("writing config schema: %w", err)
+	}
+	fmt.Println("Created restgen.schema.json")
+
 	// Create schemas directory and example
 	if err := os.MkdirAll("schemas", 0755); err != nil {
 		return fmt.Errorf("creating schemas dir: %w", err)
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/config"
+	"github.com/borderlesshq/restgen/internal/ir"
+	"github.com/borderlesshq/restgen/internal/routes"
+	"github.com/borderlesshq/restgen/internal/schema"
+)
+
+// runRoutes prints every endpoint of the configured schemas and the routes
+// that conflict. It returns conflicting=true if any conflict breaks routing.
+func runRoutes(args []string) (conflicting bool, err error) {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	format := fs.String("format", "table", "output format: table or json")
+	methods := fs.String("method", "", "only show these methods (comma-separated)")
+	prefix := fs.String("prefix", "", "only show paths starting with this prefix")
+	schemaFilter := fs.String("schema", "", "only show routes of schemas whose file or name matches this glob")
+	fs.Parse(args)
+
+	if *format != "table" && *format != "json" {
+		return false, fmt.Errorf("unknown format %q (want table or json)", *format)
+	}
+
+	cfg, err := loadConfig(fs, *configPath)
+	if err != nil {
+		return false, err
+	}
+	schemas, err := loadSchemas(gen.OSFS{}, cfg, nil, "")
+	if err != nil {
+		return false, err
+	}
+
+	var files []string
+	for file := range schemas {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var list []ir.Schema
+	for _, file := range files {
+		list = append(list, schemas[file])
+	}
+
+	router, err := strictestRouter(cfg)
+	if err != nil {
+		return false, err
+	}
+	all := routes.FromIR(list)
+	conflicts := routes.Conflicts(all, router)
+
+	show := func(r routes.Route) bool {
+		if *methods != "" && !containsFold(strings.Split(*methods, ","), r.Method) {
+			return false
+		}
+		if *prefix != "" && !strings.HasPrefix(r.Path, *prefix) {
+			return false
+		}
+		if *schemaFilter != "" {
+			byFile, _ := path.Match(*schemaFilter, r.Schema)
+			byName, _ := path.Match(*schemaFilter, schema.BaseName(r.Schema))
+			if !byFile && !byName {
+				return false
+			}
+		}
+		return true
+	}
+
+	shown := []routes.Route{}
+	for _, r := range all {
+		if show(r) {
+			shown = append(shown, r)
+		}
+	}
+	routes.Sort(shown)
+
+	// Only the conflicts of the routes shown
+	relevant := []routes.Conflict{}
+	for _, c := range conflicts {
+		if show(c.Routes[0]) || show(c.Routes[1]) {
+			relevant = append(relevant, c)
+		}
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(map[string]any{
+			"routes":    shown,
+			"conflicts": relevant,
+		}, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("encoding routes: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printRoutes(shown, relevant)
+	}
+
+	return routes.HasErrors(relevant), nil
+}
+
+func printRoutes(shown []routes.Route, conflicts []routes.Conflict) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tARGS\tRETURNS")
+	for _, r := range shown {
+		var args []string
+		for _, a := range r.Args {
+			args = append(args, fmt.Sprintf("%s:%s", a.Name, a.Source))
+		}
+		argList := strings.Join(args, ", ")
+		if argList == "" {
+			argList = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Handler, argList, r.Returns)
+	}
+	w.Flush()
+
+	if len(conflicts) > 0 {
+		fmt.Println()
+	}
+	for _, c := range conflicts {
+		level := "warning"
+		if c.Error {
+			level = "error"
+		}
+		fmt.Printf("%s: %s: %s\n", level, c.Kind, c.Message)
+	}
+}
+
+// strictestRouter returns the router conflicts are judged by: net/http if
+// any target uses it, since it refuses routes chi accepts.
+func strictestRouter(cfg *gen.Config) (string, error) {
+	targets, err := gen.Targets(cfg)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range targets {
+		if t.Router == config.RouterStdlib {
+			return config.RouterStdlib, nil
+		}
+	}
+	return config.RouterChi, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
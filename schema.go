@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/borderlesshq/restgen/gen"
+)
+
+// runSchema prints the JSON Schema for restgen.yaml.
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("o", "", "write the schema to this file instead of stdout")
+	fs.Parse(args)
+
+	if *output == "" {
+		_, err := os.Stdout.Write(gen.ConfigSchema())
+		return err
+	}
+	return os.WriteFile(*output, gen.ConfigSchema(), 0644)
+}
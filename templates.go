@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/borderlesshq/restgen/gen"
+)
+
+// runTemplates dispatches `restgen templates <subcommand>`.
+func runTemplates(args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return errors.New("usage: restgen templates export [-c config.yaml] [-o dir] [-force]")
+	}
+	return runTemplatesExport(args[1:])
+}
+
+// runTemplatesExport writes the built-in templates to a directory so they
+// can be used as a starting point for overrides.
+func runTemplatesExport(args []string) error {
+	fs := flag.NewFlagSet("templates export", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	output := fs.String("o", "", "directory to write to (default: templates from config, or ./templates)")
+	force := fs.Bool("force", false, "overwrite existing template files")
+	fs.Parse(args)
+
+	dir := *output
+	if dir == "" {
+		cfg, err := loadConfig(fs, *configPath)
+		if err != nil {
+			return err
+		}
+		dir = cfg.Templates
+	}
+	if dir == "" {
+		dir = "templates"
+	}
+
+	builtin := gen.BuiltinTemplates()
+
+	var names []string
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Refuse up front so we never leave a half-exported directory
+	if !*force {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists (use -force to overwrite)", path)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(builtin[name]), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Printf("Created %s\n", path)
+	}
+
+	fmt.Printf("\nSet 'templates: %s' in restgen.yaml to use them.\n", dir)
+	return nil
+}
+
+// templateInputs returns the content of every template override, so the
+// cache is invalidated when a template changes.
+func templateInputs(cfg *gen.Config) [][]byte {
+	if cfg.Templates == "" {
+		return nil
+	}
+
+	var names []string
+	for name := range gen.BuiltinTemplates() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var inputs [][]byte
+	for _, name := range names {
+		// Missing overrides hash as empty
+		data, _ := os.ReadFile(filepath.Join(cfg.Templates, name))
+		inputs = append(inputs, []byte(name), data)
+	}
+	return inputs
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3".
+// When empty it is derived from the module or VCS build info.
+var version string
+
+// restgenVersion returns the version of this restgen binary.
+func restgenVersion() string {
+	if version != "" {
+		return version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	// Built from a checkout: use the commit so caches are invalidated
+	// whenever the generator itself changes.
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if revision != "" {
+		return "devel-" + revision + modified
+	}
+
+	return "devel"
+}
+
+func runVersion() {
+	fmt.Printf("restgen %s\n", restgenVersion())
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/borderlesshq/restgen/gen"
+	"github.com/borderlesshq/restgen/internal/parser"
+)
+
+// runWatch polls the config, every schema and every transitively included
+// SDL file, and regenerates the affected schemas when something changes.
+// Polling (rather than inotify) keeps it working inside containers.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	configPath := fs.String("c", "restgen.yaml", "config file path")
+	fs.StringVar(configPath, "config", "restgen.yaml", "config file path")
+	interval := fs.Duration("interval", 500*time.Millisecond, "polling interval")
+	verifyTypes := fs.Bool("verify", false, "type-check generated code before writing")
+	fs.Parse(args)
+
+	w := &watcher{
+		configPath:  *configPath,
+		fs:          fs,
+		verifyTypes: *verifyTypes,
+		closures:    make(map[string][]string),
+	}
+
+	// Initial full generation
+	w.reload()
+	w.regenerate(w.schemaFiles)
+	w.snapshot()
+
+	fmt.Printf("Watching %d files for changes (Ctrl+C to stop)...\n", len(w.stamps))
+
+	for {
+		time.Sleep(*interval)
+
+		changed := w.changedFiles()
+		if len(changed) == 0 {
+			continue
+		}
+
+		for _, path := range changed {
+			fmt.Printf("\nChanged: %s\n", path)
+		}
+
+		configChanged := false
+		for _, path := range changed {
+			if path == w.absConfig() {
+				configChanged = true
+			}
+		}
+
+		if configChanged {
+			// Everything depends on the config
+			w.reload()
+			w.regenerate(w.schemaFiles)
+		} else {
+			previous := make(map[string]bool)
+			for _, f := range w.schemaFiles {
+				previous[f] = true
+			}
+
+			// Re-glob so new schema files are picked up
+			w.reload()
+
+			var affected []string
+			for _, f := range w.schemaFiles {
+				if !previous[f] || w.affectedBy(f, changed) {
+					affected = append(affected, f)
+				}
+			}
+			w.regenerate(affected)
+		}
+
+		w.snapshot()
+	}
+}
+
+type watcher struct {
+	configPath  string
+	fs          *flag.FlagSet // for loadConfig
+	verifyTypes bool
+
+	cfg         *gen.Config
+	schemaFiles []string
+	// closures maps a schema file to the absolute paths of the SDL files
+	// it transitively includes.
+	closures map[string][]string
+	stamps   map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func (w *watcher) absConfig() string {
+	abs, err := filepath.Abs(w.configPath)
+	if err != nil {
+		return w.configPath
+	}
+	return abs
+}
+
+// reload re-reads the config, re-globs the schema files and rebuilds the
+// include graph. Errors are printed; the previous state is kept.
+func (w *watcher) reload() {
+	cfg, err := loadConfig(w.fs, w.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return
+	}
+	w.cfg = cfg
+
+	schemaFiles, err := gen.SchemaFiles(gen.OSFS{}, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+	w.schemaFiles = schemaFiles
+
+	p := parser.New()
+	for _, f := range schemaFiles {
+		closure, err := p.IncludeClosure(f)
+		if err != nil {
+			// Keep the previous closure so we still notice when the
+			// broken include is fixed.
+			continue
+		}
+		w.closures[f] = closure
+	}
+}
+
+// affectedBy reports whether schemaFile or anything it includes changed.
+func (w *watcher) affectedBy(schemaFile string, changed []string) bool {
+	abs, err := filepath.Abs(schemaFile)
+	if err != nil {
+		abs = schemaFile
+	}
+
+	deps := map[string]bool{abs: true}
+	for _, inc := range w.closures[schemaFile] {
+		deps[inc] = true
+	}
+
+	for _, path := range changed {
+		if deps[path] {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerate runs generation for schemaFiles, printing diagnostics instead
+// of exiting on failure.
+func (w *watcher) regenerate(schemaFiles []string) {
+	if w.cfg == nil || len(schemaFiles) == 0 {
+		return
+	}
+
+	start := time.Now()
+	_, err := generateFiles(gen.Options{
+		Config:      w.cfg,
+		SchemaFiles: schemaFiles,
+		Verify:      w.verifyTypes,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return
+	}
+	fmt.Printf("Done in %s\n", time.Since(start).Round(time.Millisecond))
+}
+
+// watchedFiles returns the absolute paths of every file being watched.
+func (w *watcher) watchedFiles() []string {
+	set := map[string]bool{w.absConfig(): true}
+	for _, f := range w.schemaFiles {
+		if abs, err := filepath.Abs(f); err == nil {
+			set[abs] = true
+		}
+		for _, inc := range w.closures[f] {
+			set[inc] = true
+		}
+	}
+
+	var files []string
+	for f := range set {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// snapshot records the current stamp of every watched file.
+func (w *watcher) snapshot() {
+	w.stamps = make(map[string]fileStamp)
+	for _, f := range w.watchedFiles() {
+		w.stamps[f] = stat(f)
+	}
+}
+
+// changedFiles returns watched files whose stamp differs from the snapshot,
+// plus any new schema files matched by the config's globs.
+func (w *watcher) changedFiles() []string {
+	var changed []string
+	for f, old := range w.stamps {
+		if stat(f) != old {
+			changed = append(changed, f)
+		}
+	}
+
+	if w.cfg != nil {
+		if schemaFiles, err := gen.SchemaFiles(gen.OSFS{}, w.cfg); err == nil {
+			for _, f := range schemaFiles {
+				abs, err := filepath.Abs(f)
+				if err != nil {
+					continue
+				}
+				if _, ok := w.stamps[abs]; !ok {
+					changed = append(changed, abs)
+				}
+			}
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+func stat(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}